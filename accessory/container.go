@@ -35,6 +35,7 @@ func (m *Container) RemoveAccessory(a *Accessory) {
 	for i, accessory := range m.Accessories {
 		if accessory == a {
 			m.Accessories = append(m.Accessories[:i], m.Accessories[i+1:]...)
+			return
 		}
 	}
 }
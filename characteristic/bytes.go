@@ -35,6 +35,14 @@ func (bs *Bytes) OnValueRemoteUpdate(fn func([]byte)) {
 	})
 }
 
+// OnValueRemoteWriteResponse calls fn when the value was written by a client, which
+// requested a write response. The bytes returned by fn are sent back to the client.
+func (bs *Bytes) OnValueRemoteWriteResponse(fn func([]byte) []byte) {
+	bs.OnWriteResponse(func(conn net.Conn, c *Characteristic, value interface{}) interface{} {
+		return base64FromBytes(fn(bs.GetValue()))
+	})
+}
+
 func base64FromBytes(b []byte) string {
 	return base64.StdEncoding.EncodeToString(b)
 }
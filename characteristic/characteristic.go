@@ -10,6 +10,9 @@ import (
 type ConnChangeFunc func(conn net.Conn, c *Characteristic, newValue, oldValue interface{})
 type ChangeFunc func(c *Characteristic, newValue, oldValue interface{})
 type GetFunc func() interface{}
+type WriteResponseFunc func(conn net.Conn, c *Characteristic, value interface{}) interface{}
+type ReadFunc func(conn net.Conn, c *Characteristic) (interface{}, error)
+type WriteFunc func(conn net.Conn, c *Characteristic, value interface{}) error
 
 // Characteristic is a HomeKit characteristic.
 type Characteristic struct {
@@ -27,12 +30,15 @@ type Characteristic struct {
 	MinValue  interface{} `json:"minValue,omitempty"`
 	StepValue interface{} `json:"minStep,omitempty"`
 
-	// unused
+	// Deprecated: Events are enabled per client session and this field is not used anymore.
 	Events bool `json:"-"`
 
 	connValueUpdateFuncs []ConnChangeFunc
 	valueChangeFuncs     []ChangeFunc
 	valueGetFunc         GetFunc
+	writeResponseFunc    WriteResponseFunc
+	valueReadFunc        ReadFunc
+	valueWriteFunc       WriteFunc
 }
 
 // writeOnlyPerms returns true when permissions only include write permission
@@ -53,6 +59,16 @@ func noWritePerms(permissions []string) bool {
 	return true
 }
 
+// hasPerm returns true when permissions include perm
+func hasPerm(permissions []string, perm string) bool {
+	for _, value := range permissions {
+		if value == perm {
+			return true
+		}
+	}
+	return false
+}
+
 // NewCharacteristic returns a characteristic
 // If no permissions are specified, the value of PermsAll() is used.
 //
@@ -79,6 +95,64 @@ func (c *Characteristic) OnValueGet(fn GetFunc) {
 	c.valueGetFunc = fn
 }
 
+// OnValueRead sets fn, which is called when a client reads the value.
+// When fn returns an error, the value stays the same and the client is
+// informed about the failure.
+func (c *Characteristic) OnValueRead(fn ReadFunc) {
+	c.valueReadFunc = fn
+}
+
+// OnValueWrite sets fn, which is called before a value written by a client is set.
+// When fn returns an error, the value stays the same and the client is
+// informed about the failure.
+func (c *Characteristic) OnValueWrite(fn WriteFunc) {
+	c.valueWriteFunc = fn
+}
+
+// ReadValueFromConnection returns the value read by a client.
+// The method returns an error when the characteristic is write-only
+// or the function set with OnValueRead fails.
+func (c *Characteristic) ReadValueFromConnection(conn net.Conn) (interface{}, error) {
+	if c.isWriteOnly() == true {
+		return nil, ErrWriteOnly
+	}
+
+	if c.valueReadFunc != nil {
+		value, err := c.valueReadFunc(conn, c)
+		if err != nil {
+			return nil, err
+		}
+		c.updateValue(value, conn, false)
+		return c.Value, nil
+	}
+
+	return c.getValue(conn), nil
+}
+
+// WriteValueFromConnection sets the value written by a client.
+// The method returns an error when the characteristic has no write permission
+// or the function set with OnValueWrite fails.
+func (c *Characteristic) WriteValueFromConnection(value interface{}, conn net.Conn) error {
+	if c.hasWritePerms() == false {
+		return ErrReadOnly
+	}
+
+	if c.valueWriteFunc != nil {
+		if err := c.valueWriteFunc(conn, c, c.convert(value)); err != nil {
+			return err
+		}
+	}
+
+	c.updateValue(value, conn, true)
+
+	return nil
+}
+
+// SupportsEvents returns true when clients can enable events for the characteristic.
+func (c *Characteristic) SupportsEvents() bool {
+	return hasPerm(c.Perms, PermEvents)
+}
+
 func (c *Characteristic) UpdateValue(value interface{}) {
 	c.updateValue(value, nil, false)
 }
@@ -87,10 +161,33 @@ func (c *Characteristic) UpdateValueFromConnection(value interface{}, conn net.C
 	c.updateValue(value, conn, true)
 }
 
+// OnWriteResponse sets fn, which returns the value sent back to a client
+// that requested a write response when writing value.
+func (c *Characteristic) OnWriteResponse(fn WriteResponseFunc) {
+	c.writeResponseFunc = fn
+}
+
+// WriteResponseFromConnection returns the value for a write response after value
+// was written by a client. When no write response function is set, the
+// current value is returned.
+func (c *Characteristic) WriteResponseFromConnection(value interface{}, conn net.Conn) interface{} {
+	if c.writeResponseFunc != nil {
+		return c.writeResponseFunc(conn, c, value)
+	}
+
+	return c.Value
+}
+
+// SetEventsEnabled sets the Events field.
+//
+// Deprecated: Events are enabled per client session, see hap.Session.SetEventsEnabled.
 func (c *Characteristic) SetEventsEnabled(enable bool) {
 	c.Events = enable
 }
 
+// EventsEnabled returns the Events field.
+//
+// Deprecated: Events are enabled per client session, see hap.Session.EventsEnabled.
 func (c *Characteristic) EventsEnabled() bool {
 	return c.Events
 }
@@ -111,12 +208,18 @@ func (c *Characteristic) Equal(other interface{}) bool {
 		value := fmt.Sprintf("%+v", c.Value)
 		otherValue := fmt.Sprintf("%+v", characteristic.Value)
 
-		return value == otherValue && c.ID == characteristic.ID && c.Type == characteristic.Type && len(c.Perms) == len(characteristic.Perms) && c.Description == characteristic.Description && c.Format == characteristic.Format && c.Unit == characteristic.Unit && c.MaxLen == characteristic.MaxLen && c.MaxValue == characteristic.MaxValue && c.MinValue == characteristic.MinValue && c.StepValue == characteristic.StepValue && c.Events == characteristic.Events
+		return value == otherValue && c.ID == characteristic.ID && c.Type == characteristic.Type && len(c.Perms) == len(characteristic.Perms) && c.Description == characteristic.Description && c.Format == characteristic.Format && c.Unit == characteristic.Unit && c.MaxLen == characteristic.MaxLen && c.MaxValue == characteristic.MaxValue && c.MinValue == characteristic.MinValue && c.StepValue == characteristic.StepValue
 	}
 
 	return false
 }
 
+// RequiresTimedWrite returns true when the characteristic must be written
+// with a timed write, which has to be prepared before.
+func (c *Characteristic) RequiresTimedWrite() bool {
+	return hasPerm(c.Perms, PermTimedWrite)
+}
+
 // model.Characteristic
 func (c *Characteristic) SetID(id int64) {
 	c.ID = id
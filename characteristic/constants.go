@@ -5,6 +5,9 @@ const (
 	PermWrite  = "pw" // can be written
 	PermEvents = "ev" // sends events
 	PermHidden = "hd" // is hidden
+
+	PermTimedWrite    = "tw" // must be written with a timed write
+	PermWriteResponse = "wr" // returns a value when written
 )
 
 // PermsAll returns read, write and event permissions
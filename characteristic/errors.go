@@ -0,0 +1,24 @@
+package characteristic
+
+import (
+	"errors"
+)
+
+// These errors are returned when reading or writing a characteristic value from a client fails.
+// Functions set with OnValueRead and OnValueWrite should return them to report failures to the client.
+var (
+	// ErrCommunicationFailure is returned when the device could not be reached.
+	ErrCommunicationFailure = errors.New("Communication with the device failed")
+
+	// ErrBusy is returned when the device is busy.
+	ErrBusy = errors.New("Device is busy")
+
+	// ErrInvalidValue is returned when the value is not valid.
+	ErrInvalidValue = errors.New("Invalid value")
+
+	// ErrReadOnly is returned when writing a characteristic without write permission.
+	ErrReadOnly = errors.New("Characteristic is read-only")
+
+	// ErrWriteOnly is returned when reading a write-only characteristic.
+	ErrWriteOnly = errors.New("Characteristic is write-only")
+)
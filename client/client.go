@@ -0,0 +1,135 @@
+package client
+
+import (
+	"github.com/brutella/hc/crypto"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/pair"
+
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultTimeout is the default timeout to connect to an accessory and to wait for a response.
+const DefaultTimeout = 10 * time.Second
+
+// Client is a HomeKit controller with a name and key pair.
+type Client struct {
+	// Timeout to connect to an accessory and to wait for a response
+	Timeout time.Duration
+
+	device   hap.Device
+	database db.Database
+}
+
+// New returns a client with a name. The key pair of the client is loaded from the database or newly created.
+// The public keys of paired accessories are stored in the database.
+func New(name string, database db.Database) (*Client, error) {
+	device, err := hap.NewDevice(name, database)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Client{
+		Timeout:  DefaultTimeout,
+		device:   device,
+		database: database,
+	}
+
+	return &c, nil
+}
+
+// Name returns the name of the client, which is used as pairing id.
+func (c *Client) Name() string {
+	return c.device.Name()
+}
+
+// PublicKey returns the long-term public key of the client.
+func (c *Client) PublicKey() []byte {
+	return c.device.PublicKey()
+}
+
+// Pair pairs with the accessory at addr (host:port) using the pin e.g. "001-02-003".
+// It returns the pairing of the accessory, which is stored in the database.
+func (c *Client) Pair(addr, pin string) (db.Pairing, error) {
+	conn, err := net.DialTimeout("tcp", addr, c.Timeout)
+	if err != nil {
+		return db.Pairing{}, err
+	}
+	defer conn.Close()
+
+	ctrl := pair.NewSetupClientController(pin, c.device, c.database)
+	if err := c.exchange(conn, "/pair-setup", ctrl.InitialPairingRequest(), ctrl); err != nil {
+		return db.Pairing{}, err
+	}
+
+	return c.database.PairingWithName(ctrl.Username())
+}
+
+// Dial connects to the accessory at addr (host:port) and verifies the pairing.
+// The returned session is encrypted.
+func (c *Client) Dial(addr string) (*Session, error) {
+	conn, err := net.DialTimeout("tcp", addr, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := bufio.NewReader(conn)
+	ctrl := pair.NewVerifyClientController(c.device, c.database)
+	if err := c.exchangeBuffered(conn, raw, "/pair-verify", ctrl.InitialKeyVerifyRequest(), ctrl); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	cryptographer, err := crypto.NewSecureClientSessionFromSharedKey(ctrl.SharedKey())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	secure := newSecureConn(conn, raw, cryptographer)
+
+	return newSession(secure, addr, ctrl.Username(), c.Timeout), nil
+}
+
+// exchange sends pairing requests to path and passes the responses to h
+// until h has no more requests.
+func (c *Client) exchange(conn net.Conn, path string, req io.Reader, h hap.ContainerHandler) error {
+	return c.exchangeBuffered(conn, bufio.NewReader(conn), path, req, h)
+}
+
+func (c *Client) exchangeBuffered(conn net.Conn, r *bufio.Reader, path string, req io.Reader, h hap.ContainerHandler) error {
+	defer conn.SetDeadline(time.Time{})
+
+	for req != nil {
+		conn.SetDeadline(time.Now().Add(c.Timeout))
+
+		body := new(bytes.Buffer)
+		if _, err := io.Copy(body, req); err != nil {
+			return err
+		}
+
+		if err := writeRequest(conn, hap.MethodPOST, path, conn.RemoteAddr().String(), hap.HTTPContentTypePairingTLV8, body.Bytes()); err != nil {
+			return err
+		}
+
+		res, err := readResponse(r)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != 200 {
+			return fmt.Errorf("POST %s failed with status %d", path, res.StatusCode)
+		}
+
+		if req, err = pair.HandleReaderForHandler(bytes.NewReader(res.Body), h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/internal/testserver"
+
+	"testing"
+	"time"
+)
+
+func newPairedSession(t *testing.T, addr string) (*Client, *Session) {
+	database, _ := db.NewTempDatabase()
+	c, err := New("Controller", database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := c.Pair(addr, testserver.Pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := p.Name, "Bridge"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	s, err := c.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, s
+}
+
+func TestPairWithWrongPin(t *testing.T) {
+	srv := testserver.Start(t, "Bridge", accessory.NewSwitch(accessory.Info{Name: "Switch"}).Accessory)
+	defer srv.Stop()
+
+	database, _ := db.NewTempDatabase()
+	c, _ := New("Controller", database)
+	if _, err := c.Pair(srv.Addr, "111-22-333"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDialUnpaired(t *testing.T) {
+	srv := testserver.Start(t, "Bridge", accessory.NewSwitch(accessory.Info{Name: "Switch"}).Accessory)
+	defer srv.Stop()
+
+	database, _ := db.NewTempDatabase()
+	c, _ := New("Controller", database)
+	if _, err := c.Dial(srv.Addr); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestReadWriteCharacteristics(t *testing.T) {
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	srv := testserver.Start(t, "Bridge", sw.Accessory)
+	defer srv.Stop()
+
+	_, s := newPairedSession(t, srv.Addr)
+	defer s.Close()
+
+	if is, want := s.AccessoryName(), "Bridge"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	as, err := s.Accessories()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := len(as), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	id := ID{sw.Accessory.GetID(), sw.Switch.On.GetID()}
+	if err := s.SetValue(id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := sw.Switch.On.GetValue(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	chs, err := s.GetCharacteristics(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := chs[0].Value, true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	srv := testserver.Start(t, "Bridge", sw.Accessory)
+	defer srv.Stop()
+
+	_, s := newPairedSession(t, srv.Addr)
+	defer s.Close()
+
+	events := make(chan data.Characteristic, 1)
+	s.OnEvent(func(c data.Characteristic) {
+		events <- c
+	})
+
+	id := ID{sw.Accessory.GetID(), sw.Switch.On.GetID()}
+	if err := s.Subscribe(id); err != nil {
+		t.Fatal(err)
+	}
+
+	sw.Switch.On.SetValue(true)
+	b, _ := hap.NewCharacteristicNotification(sw.Accessory, sw.Switch.On.Characteristic)
+	for _, conn := range srv.Context.ActiveConnections() {
+		conn.(*hap.Connection).WriteEvent(b)
+	}
+
+	select {
+	case c := <-events:
+		if is, want := c.Value, true; is != want {
+			t.Fatalf("is=%v want=%v", is, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestPairings(t *testing.T) {
+	srv := testserver.Start(t, "Bridge", accessory.NewSwitch(accessory.Info{Name: "Switch"}).Accessory)
+	defer srv.Stop()
+
+	c, s := newPairedSession(t, srv.Addr)
+	defer s.Close()
+
+	if err := s.AddPairing(db.NewPairing("Other", []byte{0x01, 0x02}, db.PermissionUser)); err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := s.Pairings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := len(ps), 2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	for _, p := range ps {
+		switch p.Name {
+		case c.Name():
+			if is, want := p.IsAdmin(), true; is != want {
+				t.Fatalf("is=%v want=%v", is, want)
+			}
+		case "Other":
+			if is, want := p.IsAdmin(), false; is != want {
+				t.Fatalf("is=%v want=%v", is, want)
+			}
+		default:
+			t.Fatal(p.Name)
+		}
+	}
+
+	if err := s.RemovePairing("Other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ps, _ := s.Pairings(); len(ps) != 1 {
+		t.Fatal(ps)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	id, err := ParseID("1.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := id, (ID{1, 10}); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if _, err := ParseID("1"); err == nil {
+		t.Fatal("expected error")
+	}
+}
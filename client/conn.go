@@ -0,0 +1,155 @@
+package client
+
+import (
+	"github.com/brutella/hc/crypto"
+
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// response is a response or an event notification from an accessory.
+type response struct {
+	Event      bool // true for "EVENT/1.0" messages
+	StatusCode int
+	Header     textproto.MIMEHeader
+	Body       []byte
+}
+
+// writeRequest writes a HTTP request with a body to w.
+func writeRequest(w io.Writer, method, path, host, contentType string, body []byte) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", method, path)
+	fmt.Fprintf(&b, "Host: %s\r\n", host)
+	if len(contentType) > 0 {
+		fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	}
+	fmt.Fprintf(&b, "Content-Length: %d\r\n", len(body))
+	fmt.Fprintf(&b, "\r\n")
+	b.Write(body)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// readResponse reads a HTTP response or an event notification from r.
+//
+// http.ReadResponse can't be used because it doesn't accept
+// the protocol specifier "EVENT/1.0" of event notifications.
+func readResponse(r *bufio.Reader) (*response, error) {
+	tp := textproto.NewReader(r)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("Malformed status line %q", line)
+	}
+
+	res := response{}
+	switch {
+	case strings.HasPrefix(fields[0], "HTTP/"):
+	case strings.HasPrefix(fields[0], "EVENT/"):
+		res.Event = true
+	default:
+		return nil, fmt.Errorf("Unsupported protocol %q", fields[0])
+	}
+
+	if res.StatusCode, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, fmt.Errorf("Malformed status code %q", fields[1])
+	}
+
+	if res.Header, err = tp.ReadMIMEHeader(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.EqualFold(res.Header.Get("Transfer-Encoding"), "chunked"):
+		if res.Body, err = ioutil.ReadAll(httputil.NewChunkedReader(r)); err != nil {
+			return nil, err
+		}
+
+		// Skip trailer
+		if _, err = tp.ReadMIMEHeader(); err != nil {
+			return nil, err
+		}
+	case len(res.Header.Get("Content-Length")) > 0:
+		n, err := strconv.Atoi(res.Header.Get("Content-Length"))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Malformed content length %q", res.Header.Get("Content-Length"))
+		}
+
+		res.Body = make([]byte, n)
+		if _, err := io.ReadFull(r, res.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	return &res, nil
+}
+
+// secureConn is a connection which encrypts written and decrypts read data.
+type secureConn struct {
+	net.Conn
+
+	raw           *bufio.Reader
+	cryptographer crypto.Cryptographer
+	decrypted     bytes.Buffer
+
+	writeMutex *sync.Mutex
+}
+
+// newSecureConn returns a connection which reads the encrypted data from raw,
+// which must read from conn, and writes the encrypted data to conn.
+func newSecureConn(conn net.Conn, raw *bufio.Reader, cryptographer crypto.Cryptographer) *secureConn {
+	return &secureConn{
+		Conn:          conn,
+		raw:           raw,
+		cryptographer: cryptographer,
+		writeMutex:    &sync.Mutex{},
+	}
+}
+
+func (c *secureConn) Read(b []byte) (int, error) {
+	if c.decrypted.Len() == 0 {
+		r, err := c.cryptographer.Decrypt(c.raw)
+		if err != nil {
+			return 0, err
+		}
+
+		if n, err := c.decrypted.ReadFrom(r); err != nil {
+			return 0, err
+		} else if n == 0 {
+			// Decrypt doesn't return an error when the connection was closed
+			return 0, io.EOF
+		}
+	}
+
+	return c.decrypted.Read(b)
+}
+
+func (c *secureConn) Write(b []byte) (int, error) {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	encrypted, err := c.cryptographer.Encrypt(bytes.NewBuffer(b))
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := io.Copy(c.Conn, encrypted); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
@@ -0,0 +1,25 @@
+// Package client implements a HomeKit controller, which pairs with accessories and controls them.
+//
+// A client pairs with an accessory by entering the pin. The keys of the client and the
+// public keys of paired accessories are stored in a database.
+//
+//	c, err := client.New("Controller", database)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	if _, err := c.Pair("192.168.0.10:12345", "00102003"); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// After pairing, the client verifies the pairing and communicates with the accessory
+// over an encrypted session.
+//
+//	s, err := c.Dial("192.168.0.10:12345")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer s.Close()
+//
+//	as, err := s.Accessories()
+package client
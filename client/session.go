@@ -0,0 +1,405 @@
+package client
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/hap/pair"
+	"github.com/brutella/hc/log"
+	"github.com/brutella/hc/util"
+
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when the session is closed.
+var ErrClosed = errors.New("Session is closed")
+
+// ID identifies a characteristic by its accessory id and characteristic id.
+type ID struct {
+	AccessoryID      int64
+	CharacteristicID int64
+}
+
+// ParseID returns the id of a characteristic with format "aid.iid" e.g. "1.10".
+func ParseID(s string) (ID, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 {
+		return ID{}, fmt.Errorf("Invalid characteristic id %s", s)
+	}
+
+	aid, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ID{}, fmt.Errorf("Invalid accessory id in %s", s)
+	}
+
+	iid, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ID{}, fmt.Errorf("Invalid characteristic id in %s", s)
+	}
+
+	return ID{aid, iid}, nil
+}
+
+func (id ID) String() string {
+	return fmt.Sprintf("%d.%d", id.AccessoryID, id.CharacteristicID)
+}
+
+// EventFunc is called when the value of a subscribed characteristic changed.
+type EventFunc func(c data.Characteristic)
+
+// Session is an encrypted session with a paired accessory.
+//
+// Requests are sent one at a time. Event notifications are received in the
+// background and passed to the functions registered with OnEvent.
+type Session struct {
+	conn      *secureConn
+	host      string
+	accessory string
+	timeout   time.Duration
+
+	requestMutex *sync.Mutex
+	responses    chan *response
+
+	eventMutex *sync.Mutex
+	eventFuncs []EventFunc
+
+	done chan struct{}
+	err  error // read error, valid after done is closed
+}
+
+func newSession(conn *secureConn, host, accessory string, timeout time.Duration) *Session {
+	s := Session{
+		conn:         conn,
+		host:         host,
+		accessory:    accessory,
+		timeout:      timeout,
+		requestMutex: &sync.Mutex{},
+		responses:    make(chan *response),
+		eventMutex:   &sync.Mutex{},
+		done:         make(chan struct{}),
+	}
+
+	go s.read()
+
+	return &s
+}
+
+// AccessoryName returns the name (pairing id) of the accessory.
+func (s *Session) AccessoryName() string {
+	return s.accessory
+}
+
+// Close closes the session.
+func (s *Session) Close() error {
+	err := s.conn.Close()
+	<-s.done
+
+	return err
+}
+
+// Done returns a channel which is closed when the session is closed,
+// e.g. because the accessory closed the connection.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// OnEvent registers fn, which is called for every characteristic value received as event.
+func (s *Session) OnEvent(fn EventFunc) {
+	s.eventMutex.Lock()
+	defer s.eventMutex.Unlock()
+
+	s.eventFuncs = append(s.eventFuncs, fn)
+}
+
+// Accessories returns the accessories with their services and characteristics.
+func (s *Session) Accessories() ([]*accessory.Accessory, error) {
+	res, err := s.do(hap.MethodGET, "/accessories", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("GET /accessories failed with status %d", res.StatusCode)
+	}
+
+	var c accessory.Container
+	if err := json.Unmarshal(res.Body, &c); err != nil {
+		return nil, err
+	}
+
+	return c.Accessories, nil
+}
+
+// GetCharacteristics returns the values of characteristics.
+// Characteristics, which could not be read, have a status set.
+func (s *Session) GetCharacteristics(ids ...ID) ([]data.Characteristic, error) {
+	var strs []string
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+
+	path := "/characteristics?id=" + strings.Join(strs, ",")
+	res, err := s.do(hap.MethodGET, path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != 200 && res.StatusCode != 207 {
+		return nil, fmt.Errorf("GET %s failed with status %d", path, res.StatusCode)
+	}
+
+	var chs data.Characteristics
+	if err := json.Unmarshal(res.Body, &chs); err != nil {
+		return nil, err
+	}
+
+	return chs.Characteristics, nil
+}
+
+// PutCharacteristics writes the values or event settings of characteristics.
+// An error is returned when a characteristic could not be written.
+func (s *Session) PutCharacteristics(chs ...data.Characteristic) error {
+	b, err := json.Marshal(data.Characteristics{Characteristics: chs})
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(hap.MethodPUT, "/characteristics", hap.HTTPContentTypeHAPJson, b)
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode {
+	case 200, 204:
+		return nil
+	case 207:
+		var result data.Characteristics
+		if err := json.Unmarshal(res.Body, &result); err != nil {
+			return err
+		}
+
+		for _, c := range result.Characteristics {
+			if status, ok := c.Status.(float64); ok == true && status != hap.StatusSuccess {
+				return fmt.Errorf("Writing characteristic %d.%d failed with status %d", c.AccessoryID, c.CharacteristicID, int(status))
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("PUT /characteristics failed with status %d", res.StatusCode)
+}
+
+// SetValue writes the value of a characteristic.
+func (s *Session) SetValue(id ID, value interface{}) error {
+	return s.PutCharacteristics(data.Characteristic{AccessoryID: id.AccessoryID, CharacteristicID: id.CharacteristicID, Value: value})
+}
+
+// Subscribe enables events for characteristics.
+func (s *Session) Subscribe(ids ...ID) error {
+	return s.putEvents(ids, true)
+}
+
+// Unsubscribe disables events for characteristics.
+func (s *Session) Unsubscribe(ids ...ID) error {
+	return s.putEvents(ids, false)
+}
+
+func (s *Session) putEvents(ids []ID, enable bool) error {
+	var chs []data.Characteristic
+	for _, id := range ids {
+		chs = append(chs, data.Characteristic{AccessoryID: id.AccessoryID, CharacteristicID: id.CharacteristicID, Events: enable})
+	}
+
+	return s.PutCharacteristics(chs...)
+}
+
+// Pairings returns the clients which are paired with the accessory.
+// The client must be paired as admin.
+func (s *Session) Pairings() ([]db.Pairing, error) {
+	in := util.NewTLV8Container()
+	in.SetByte(pair.TagPairingMethod, pair.PairingMethodList.Byte())
+	in.SetByte(pair.TagSequence, 0x01)
+
+	res, err := s.pairings(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePairings(res)
+}
+
+// AddPairing pairs the accessory with another client.
+// The client must be paired as admin.
+func (s *Session) AddPairing(p db.Pairing) error {
+	in := util.NewTLV8Container()
+	in.SetByte(pair.TagPairingMethod, pair.PairingMethodAdd.Byte())
+	in.SetByte(pair.TagSequence, 0x01)
+	in.SetString(pair.TagUsername, p.Name)
+	in.SetBytes(pair.TagPublicKey, p.PublicKey)
+	in.SetByte(pair.TagPermission, p.Permission)
+
+	_, err := s.pairings(in)
+	return err
+}
+
+// RemovePairing removes the pairing of the client with the specified name.
+// The client must be paired as admin.
+func (s *Session) RemovePairing(name string) error {
+	in := util.NewTLV8Container()
+	in.SetByte(pair.TagPairingMethod, pair.PairingMethodDelete.Byte())
+	in.SetByte(pair.TagSequence, 0x01)
+	in.SetString(pair.TagUsername, name)
+
+	_, err := s.pairings(in)
+	return err
+}
+
+// pairings sends a request to the /pairings endpoint and returns the response body.
+func (s *Session) pairings(in util.Container) ([]byte, error) {
+	res, err := s.do(hap.MethodPOST, "/pairings", hap.HTTPContentTypePairingTLV8, in.BytesBuffer().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("POST /pairings failed with status %d", res.StatusCode)
+	}
+
+	out, err := util.NewTLV8ContainerFromReader(bytes.NewReader(res.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	if code := out.GetByte(pair.TagErrCode); code != pair.ErrCodeNo.Byte() {
+		return nil, fmt.Errorf("Pairing request failed with error %d", code)
+	}
+
+	return res.Body, nil
+}
+
+// parsePairings returns the pairings of a list pairings response.
+// The pairings are separated by separator items.
+func parsePairings(b []byte) ([]db.Pairing, error) {
+	var ps []db.Pairing
+	var p *db.Pairing
+
+	for len(b) > 0 {
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return nil, errors.New("Malformed list pairings response")
+		}
+
+		tag, value := b[0], b[2:2+int(b[1])]
+		b = b[2+int(b[1]):]
+
+		if tag == pair.TagSeparator {
+			p = nil
+			continue
+		}
+
+		if p == nil {
+			switch tag {
+			case pair.TagUsername, pair.TagPublicKey, pair.TagPermission:
+				ps = append(ps, db.Pairing{})
+				p = &ps[len(ps)-1]
+			default:
+				continue
+			}
+		}
+
+		switch tag {
+		case pair.TagUsername:
+			p.Name += string(value)
+		case pair.TagPublicKey:
+			p.PublicKey = append(p.PublicKey, value...)
+		case pair.TagPermission:
+			if len(value) > 0 {
+				p.Permission = value[0]
+			}
+		}
+	}
+
+	return ps, nil
+}
+
+// do sends a request and waits for the response.
+func (s *Session) do(method, path, contentType string, body []byte) (*response, error) {
+	s.requestMutex.Lock()
+	defer s.requestMutex.Unlock()
+
+	select {
+	case <-s.done:
+		return nil, s.err
+	default:
+	}
+
+	if err := writeRequest(s.conn, method, path, s.host, contentType, body); err != nil {
+		s.conn.Close()
+		return nil, err
+	}
+
+	select {
+	case res := <-s.responses:
+		return res, nil
+	case <-s.done:
+		return nil, s.err
+	case <-time.After(s.timeout):
+		// The connection can't be used anymore because
+		// the response would be received by the next request.
+		s.conn.Close()
+		return nil, fmt.Errorf("%s %s timed out", method, path)
+	}
+}
+
+// read reads responses and event notifications until the connection is closed.
+func (s *Session) read() {
+	r := bufio.NewReader(s.conn)
+	for {
+		res, err := readResponse(r)
+		if err != nil {
+			log.Debug.Println(err)
+			s.err = ErrClosed
+			s.conn.Close()
+			close(s.done)
+			return
+		}
+
+		if res.Event == true {
+			s.handleEvent(res)
+			continue
+		}
+
+		select {
+		case s.responses <- res:
+		case <-time.After(s.timeout):
+			log.Info.Println("Unexpected response", res.StatusCode)
+		}
+	}
+}
+
+func (s *Session) handleEvent(res *response) {
+	var chs data.Characteristics
+	if err := json.Unmarshal(res.Body, &chs); err != nil {
+		log.Info.Println("Invalid event", err)
+		return
+	}
+
+	s.eventMutex.Lock()
+	fns := append([]EventFunc{}, s.eventFuncs...)
+	s.eventMutex.Unlock()
+
+	for _, c := range chs.Characteristics {
+		for _, fn := range fns {
+			fn(c)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/service"
+
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// controller implements the hcctl commands.
+type controller struct {
+	client   *client.Client
+	database db.Database
+	names    *names
+	out      io.Writer
+}
+
+// target is a characteristic selected on the command line.
+type target struct {
+	id   client.ID
+	name string // "Accessory/Characteristic"
+	char *characteristic.Characteristic
+}
+
+func (c *controller) pair(addr string, args []string) error {
+	p, err := c.client.Pair(addr, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "Paired with %s\n", p.Name)
+
+	return nil
+}
+
+func (c *controller) accessories(addr string, args []string) error {
+	s, err := c.client.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	as, err := s.Accessories()
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		fmt.Fprintf(c.out, "%d %s\n", a.ID, accessoryName(a))
+		for _, svc := range a.Services {
+			fmt.Fprintf(c.out, "  %d.%d %s\n", a.ID, svc.ID, c.names.Service(svc.Type))
+			for _, char := range svc.Characteristics {
+				fmt.Fprintf(c.out, "    %d.%d %s", a.ID, char.ID, c.names.Characteristic(char.Type))
+				if char.Value != nil {
+					fmt.Fprintf(c.out, " = %s", formatValue(char.Value, char.Unit))
+				}
+				fmt.Fprintf(c.out, " (%s %s)\n", char.Format, strings.Join(char.Perms, ","))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *controller) get(addr string, args []string) error {
+	s, err := c.client.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ts, err := c.targets(s, args)
+	if err != nil {
+		return err
+	}
+
+	var ids []client.ID
+	for _, t := range ts {
+		ids = append(ids, t.id)
+	}
+
+	chs, err := s.GetCharacteristics(ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, ch := range chs {
+		t := findTarget(ts, ch)
+		if status, ok := ch.Status.(float64); ok == true && status != 0 {
+			fmt.Fprintf(c.out, "%s %s: status %d\n", t.id, t.name, int(status))
+			continue
+		}
+		fmt.Fprintf(c.out, "%s %s = %s\n", t.id, t.name, formatValue(ch.Value, t.char.Unit))
+	}
+
+	return nil
+}
+
+func (c *controller) set(addr string, args []string) error {
+	s, err := c.client.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ts, err := c.targets(s, args[:1])
+	if err != nil {
+		return err
+	}
+
+	for _, t := range ts {
+		v, err := parseValue(args[1], t.char.Format)
+		if err != nil {
+			return err
+		}
+
+		if err := s.SetValue(t.id, v); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.out, "%s %s = %s\n", t.id, t.name, formatValue(v, t.char.Unit))
+	}
+
+	return nil
+}
+
+func (c *controller) watch(addr string, args []string) error {
+	s, err := c.client.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ts, err := c.targets(s, args)
+	if err != nil {
+		return err
+	}
+
+	var ids []client.ID
+	for _, t := range ts {
+		ids = append(ids, t.id)
+	}
+
+	s.OnEvent(func(ch data.Characteristic) {
+		t := findTarget(ts, ch)
+		fmt.Fprintf(c.out, "%s %s %s = %s\n", timestamp(), t.id, t.name, formatValue(ch.Value, t.char.Unit))
+	})
+
+	if err := s.Subscribe(ids...); err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	select {
+	case <-sig:
+		return s.Unsubscribe(ids...)
+	case <-s.Done():
+		return fmt.Errorf("Connection to %s closed", addr)
+	}
+}
+
+func (c *controller) pairings(addr string, args []string) error {
+	s, err := c.client.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ps, err := s.Pairings()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range ps {
+		perm := "user"
+		if p.IsAdmin() == true {
+			perm = "admin"
+		}
+		fmt.Fprintf(c.out, "%s %s %s\n", p.Name, perm, hex.EncodeToString(p.PublicKey))
+	}
+
+	return nil
+}
+
+func (c *controller) unpair(addr string, args []string) error {
+	s, err := c.client.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.RemovePairing(args[0]); err != nil {
+		return err
+	}
+
+	// The accessory is not paired with this controller anymore
+	if args[0] == c.client.Name() {
+		c.database.DeletePairing(s.AccessoryName())
+	}
+
+	fmt.Fprintf(c.out, "Removed pairing %s\n", args[0])
+
+	return nil
+}
+
+// targets returns the characteristics selected by args.
+func (c *controller) targets(s *client.Session, args []string) ([]target, error) {
+	as, err := s.Accessories()
+	if err != nil {
+		return nil, err
+	}
+
+	var ts []target
+	for _, arg := range args {
+		matches := c.match(as, arg)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("Characteristic %s not found", arg)
+		}
+		ts = append(ts, matches...)
+	}
+
+	return ts, nil
+}
+
+// match returns the characteristics of as, which match arg.
+func (c *controller) match(as []*accessory.Accessory, arg string) []target {
+	id, idErr := client.ParseID(arg)
+
+	accName, charName := "", arg
+	if i := strings.LastIndex(arg, "/"); i >= 0 {
+		accName, charName = arg[:i], arg[i+1:]
+	}
+
+	var ts []target
+	for _, a := range as {
+		if len(accName) > 0 && matchName(accessoryName(a), accName) == false {
+			continue
+		}
+
+		for _, svc := range a.Services {
+			for _, char := range svc.Characteristics {
+				name := c.names.Characteristic(char.Type)
+				if idErr == nil {
+					if id.AccessoryID != a.ID || id.CharacteristicID != char.ID {
+						continue
+					}
+				} else if matchName(name, charName) == false {
+					continue
+				}
+
+				ts = append(ts, target{
+					id:   client.ID{AccessoryID: a.ID, CharacteristicID: char.ID},
+					name: accessoryName(a) + "/" + name,
+					char: char,
+				})
+			}
+		}
+	}
+
+	return ts
+}
+
+// findTarget returns the target of ch.
+func findTarget(ts []target, ch data.Characteristic) target {
+	for _, t := range ts {
+		if t.id.AccessoryID == ch.AccessoryID && t.id.CharacteristicID == ch.CharacteristicID {
+			return t
+		}
+	}
+
+	return target{
+		id:   client.ID{AccessoryID: ch.AccessoryID, CharacteristicID: ch.CharacteristicID},
+		char: &characteristic.Characteristic{},
+	}
+}
+
+// accessoryName returns the value of the name characteristic
+// in the accessory information service.
+func accessoryName(a *accessory.Accessory) string {
+	for _, svc := range a.Services {
+		if minifyUUID(svc.Type) != service.TypeAccessoryInformation {
+			continue
+		}
+
+		for _, char := range svc.Characteristics {
+			if minifyUUID(char.Type) == characteristic.TypeName {
+				if name, ok := char.Value.(string); ok == true {
+					return name
+				}
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d", a.ID)
+}
+
+// parseValue returns the value of s for a characteristic with format.
+func parseValue(s string, format string) (interface{}, error) {
+	switch format {
+	case characteristic.FormatBool:
+		switch strings.ToLower(s) {
+		case "true", "on", "yes", "1":
+			return true, nil
+		case "false", "off", "no", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("Invalid bool value %s", s)
+	case characteristic.FormatUInt8, characteristic.FormatUInt16, characteristic.FormatUInt32, characteristic.FormatUInt64:
+		return strconv.ParseUint(s, 10, 64)
+	case characteristic.FormatInt32:
+		return strconv.ParseInt(s, 10, 32)
+	case characteristic.FormatFloat:
+		return strconv.ParseFloat(s, 64)
+	}
+
+	// string, data and tlv8 (base64) values are written as they are
+	return s, nil
+}
+
+// formatValue returns v with the unit.
+func formatValue(v interface{}, unit string) string {
+	var str string
+	switch v := v.(type) {
+	case string:
+		str = strconv.Quote(v)
+	default:
+		str = fmt.Sprintf("%v", v)
+	}
+
+	switch unit {
+	case "":
+		return str
+	case characteristic.UnitPercentage:
+		return str + "%"
+	case characteristic.UnitCelsius:
+		return str + "°C"
+	}
+
+	return str + " " + unit
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/internal/testserver"
+
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newController(t *testing.T) (*controller, *bytes.Buffer) {
+	database, _ := db.NewTempDatabase()
+	c, err := client.New("hcctl", database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := loadNames("../../gen/metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+
+	return &controller{client: c, database: database, names: n, out: out}, out
+}
+
+func TestController(t *testing.T) {
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	srv := testserver.Start(t, "Bridge", sw.Accessory)
+	defer srv.Stop()
+
+	ctl, out := newController(t)
+	if err := ctl.pair(srv.Addr, []string{testserver.Pin}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := ctl.accessories(srv.Addr, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := out.String(); strings.Contains(x, "1 Switch\n") == false || strings.Contains(x, "Accessory Information") == false {
+		t.Fatalf("unexpected output %s", x)
+	}
+
+	if err := ctl.set(srv.Addr, []string{"Switch/On", "true"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := sw.Switch.On.GetValue(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	out.Reset()
+	if err := ctl.get(srv.Addr, []string{"on"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.String(), "1.11 Switch/On = true\n"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	out.Reset()
+	if err := ctl.pairings(srv.Addr, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := strings.HasPrefix(out.String(), "hcctl admin "), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if err := ctl.unpair(srv.Addr, []string{"hcctl"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ctl.database.PairingWithName("Bridge"); err == nil {
+		t.Fatal("expected pairing to be removed")
+	}
+}
+
+func TestUnknownCharacteristic(t *testing.T) {
+	srv := testserver.Start(t, "Bridge", accessory.NewSwitch(accessory.Info{Name: "Switch"}).Accessory)
+	defer srv.Stop()
+
+	ctl, _ := newController(t)
+	if err := ctl.pair(srv.Addr, []string{testserver.Pin}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctl.get(srv.Addr, []string{"Current Temperature"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	if v, err := parseValue("on", "bool"); err != nil || v != true {
+		t.Fatalf("is=%v want=%v", v, true)
+	}
+
+	if _, err := parseValue("maybe", "bool"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if v, err := parseValue("42", "uint8"); err != nil || v != uint64(42) {
+		t.Fatalf("is=%v want=%v", v, 42)
+	}
+
+	if v, err := parseValue("21.5", "float"); err != nil || v != 21.5 {
+		t.Fatalf("is=%v want=%v", v, 21.5)
+	}
+}
+
+func TestMinifyUUID(t *testing.T) {
+	if is, want := minifyUUID("00000025-0000-1000-8000-0026BB765291"), "25"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := minifyUUID("3e"), "3E"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,148 @@
+// hcctl is a command line tool to pair with and control HomeKit accessories.
+//
+// It pairs as controller with an accessory and stores the keys in a database directory.
+// The names of services and characteristics are read from the HomeKit metadata file.
+//
+//	hcctl pair 192.168.0.10:12345 001-02-003
+//	hcctl accessories 192.168.0.10:12345
+//	hcctl get 192.168.0.10:12345 1.10 "Current Temperature"
+//	hcctl set 192.168.0.10:12345 Lamp/On true
+//	hcctl watch 192.168.0.10:12345 On
+//	hcctl pairings 192.168.0.10:12345
+//	hcctl unpair 192.168.0.10:12345 hcctl
+//
+// Characteristics are specified by their id ("aid.iid"), by their name ("On")
+// or by the name of the accessory and characteristic ("Lamp/On").
+package main
+
+import (
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/log"
+
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var MetadataPath = os.ExpandEnv("$GOPATH/src/github.com/brutella/hc/gen/metadata.json")
+
+const usage = `Usage: hcctl [flags] <command> <address> [arguments]
+
+Commands:
+  pair <address> <pin>                    Pair with the accessory
+  accessories <address>                   List accessories, services and characteristics
+  get <address> <characteristic>...       Read characteristic values
+  set <address> <characteristic> <value>  Write a characteristic value
+  watch <address> <characteristic>...     Print value changes of characteristics
+  pairings <address>                      List the pairings of the accessory
+  unpair <address> <name>                 Remove a pairing from the accessory
+
+Characteristics are specified as "aid.iid", "Name" or "Accessory/Name".
+
+Flags:
+`
+
+func main() {
+	var (
+		dbPath   = flag.String("db", "./hcctl", "Path to the database directory")
+		name     = flag.String("name", "hcctl", "Name of the controller")
+		metadata = flag.String("metadata", MetadataPath, "Path to the HomeKit metadata file")
+		timeout  = flag.Duration("timeout", client.DefaultTimeout, "Timeout to connect and wait for responses")
+		verbose  = flag.Bool("v", false, "Print debug output")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *verbose == true {
+		log.Debug.Enable()
+	}
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	database, err := db.NewDatabase(filepath.Clean(*dbPath))
+	if err != nil {
+		fatal(err)
+	}
+
+	c, err := client.New(*name, database)
+	if err != nil {
+		fatal(err)
+	}
+	c.Timeout = *timeout
+
+	n, err := loadNames(*metadata)
+	if err != nil {
+		log.Debug.Println("Could not load metadata:", err)
+	}
+
+	ctl := &controller{
+		client:   c,
+		database: database,
+		names:    n,
+		out:      os.Stdout,
+	}
+
+	cmd, addr, params := args[0], args[1], args[2:]
+	switch cmd {
+	case "pair":
+		err = exactArgs(params, 1, ctl.pair, addr)
+	case "accessories", "ls":
+		err = exactArgs(params, 0, ctl.accessories, addr)
+	case "get":
+		err = minArgs(params, 1, ctl.get, addr)
+	case "set":
+		err = exactArgs(params, 2, ctl.set, addr)
+	case "watch":
+		err = minArgs(params, 1, ctl.watch, addr)
+	case "pairings":
+		err = exactArgs(params, 0, ctl.pairings, addr)
+	case "unpair":
+		err = exactArgs(params, 1, ctl.unpair, addr)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+}
+
+type command func(addr string, args []string) error
+
+func exactArgs(args []string, n int, cmd command, addr string) error {
+	if len(args) != n {
+		return fmt.Errorf("Invalid number of arguments (expected %d)", n)
+	}
+
+	return cmd(addr, args)
+}
+
+func minArgs(args []string, n int, cmd command, addr string) error {
+	if len(args) < n {
+		return fmt.Errorf("Invalid number of arguments (expected at least %d)", n)
+	}
+
+	return cmd(addr, args)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "hcctl:", err)
+	os.Exit(1)
+}
+
+// timestamp returns the current time formatted for event output.
+func timestamp() string {
+	return time.Now().Format("15:04:05")
+}
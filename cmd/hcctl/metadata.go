@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/brutella/hc/gen"
+
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// names maps service and characteristic types to human readable names.
+type names struct {
+	services        map[string]string
+	characteristics map[string]string
+}
+
+// loadNames returns the names of the services and characteristics in the metadata file at path.
+// If the file could not be read, types are printed instead of names.
+func loadNames(path string) (*names, error) {
+	n := names{
+		services:        map[string]string{},
+		characteristics: map[string]string{},
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &n, err
+	}
+
+	metadata := gen.Metadata{}
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return &n, err
+	}
+
+	for _, svc := range metadata.Services {
+		n.services[minifyUUID(svc.UUID)] = svc.Name
+	}
+
+	for _, char := range metadata.Characteristics {
+		n.characteristics[minifyUUID(char.UUID)] = char.Name
+	}
+
+	return &n, nil
+}
+
+// Service returns the name of the service type typ.
+func (n *names) Service(typ string) string {
+	if name, ok := n.services[minifyUUID(typ)]; ok == true {
+		return name
+	}
+
+	return typ
+}
+
+// Characteristic returns the name of the characteristic type typ.
+func (n *names) Characteristic(typ string) string {
+	if name, ok := n.characteristics[minifyUUID(typ)]; ok == true {
+		return name
+	}
+
+	return typ
+}
+
+// matchName returns true if name equals s ignoring case and spaces.
+// "current temperature" therefore matches the "Current Temperature" characteristic.
+func matchName(name, s string) bool {
+	strip := func(s string) string {
+		return strings.ToLower(strings.Replace(s, " ", "", -1))
+	}
+
+	return strip(name) == strip(s)
+}
+
+// minifyUUID returns the short form of an Apple-defined UUID.
+// For example "00000025-0000-1000-8000-0026BB765291" is minified to "25".
+func minifyUUID(s string) string {
+	s = strings.ToUpper(s)
+	if strings.HasSuffix(s, "-0000-1000-8000-0026BB765291") == false && strings.Contains(s, "-") == true {
+		return s
+	}
+
+	uuidRegexp := regexp.MustCompile(`^([0-9A-F]*)`)
+	if str := uuidRegexp.FindString(s); len(str) > 0 {
+		return strings.TrimLeft(str, "0")
+	}
+
+	return s
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"time"
 
 	"github.com/brutella/hc/util"
 	"github.com/gosexy/to"
@@ -24,9 +25,43 @@ type Config struct {
 	IP string
 
 	// Pin with has to be entered on iOS client to pair with the accessory
-	// When empty, the pin 00102003 is used
+	// When empty, a random pin is generated and stored
 	Pin string
 
+	// Setup id which is part of the setup payload (QR code) e.g. "7OSX"
+	// It must consist of 4 digits or uppercase letters
+	// When empty, a random setup id is generated and stored
+	SetupId string
+
+	// Minimum interval between two events of the same characteristic sent to a client
+	// When empty, the interval of 1 second recommended by HAP is used
+	// Events of programmable switches (button presses) are always sent immediately
+	EventInterval time.Duration
+
+	// Interval in which empty event notifications are sent to connected clients
+	// to detect abandoned connections
+	// When empty, no keep-alive notifications are sent
+	KeepAliveInterval time.Duration
+
+	// Period of TCP keep-alive probes on client connections
+	// When empty, the default keep-alive settings are used
+	TCPKeepAlivePeriod time.Duration
+
+	// Maximum duration of a write to a client connection
+	// Connections whose writes fail or time out are closed
+	// When empty, writes don't time out
+	WriteTimeout time.Duration
+
+	// Store the values of writable characteristics and restore them when the transport is created
+	// Values are stored by accessory and characteristic id
+	// When empty, values are not stored
+	PersistValues bool
+
+	// Delay after which changed characteristic values are stored
+	// Changes within this delay are written at once to reduce writes to the storage
+	// When empty, a delay of 1 second is used
+	PersistDelay time.Duration
+
 	name         string // Accessory name
 	id           string // Accessory id
 	servePort    int    // Actual port the server listens at (might be differen than Port field)
@@ -41,22 +76,23 @@ type Config struct {
 
 func defaultConfig(name string) *Config {
 	return &Config{
-		StoragePath:  name,
-		Pin:          "00102003", // default pin
-		Port:         "",         // empty string means that we get port from assigned by the system
-		name:         name,
-		id:           util.MAC48Address(util.RandomHexString()),
-		version:      1,
-		state:        1,
-		protocol:     "1.0",
-		discoverable: true,
-		mfiCompliant: false,
+		StoragePath:   name,
+		Port:          "", // empty string means that we get port from assigned by the system
+		EventInterval: time.Second,
+		PersistDelay:  time.Second,
+		name:          name,
+		id:            util.MAC48Address(util.RandomHexString()),
+		version:       1,
+		state:         1,
+		protocol:      "1.0",
+		discoverable:  true,
+		mfiCompliant:  false,
 	}
 }
 
 // txtRecords returns the config formatted as mDNS txt records
 func (cfg Config) txtRecords() map[string]string {
-	return map[string]string{
+	txt := map[string]string{
 		"pv": cfg.protocol,
 		"id": cfg.id,
 		"c#": fmt.Sprintf("%d", cfg.version),
@@ -66,9 +102,16 @@ func (cfg Config) txtRecords() map[string]string {
 		"md": cfg.name,
 		"ci": fmt.Sprintf("%d", cfg.categoryId),
 	}
+
+	if len(cfg.SetupId) > 0 {
+		txt["sh"] = setupHash(cfg.SetupId, cfg.id)
+	}
+
+	return txt
 }
 
-// loads load the id, version and config hash
+// loads load the id, version, config hash, pin and setup id
+// The stored pin and setup id are only used when they are not set.
 func (cfg *Config) load(storage util.Storage) {
 	if b, err := storage.Get("uuid"); err == nil && len(b) > 0 {
 		cfg.id = string(b)
@@ -81,16 +124,26 @@ func (cfg *Config) load(storage util.Storage) {
 	if b, err := storage.Get("configHash"); err == nil && len(b) > 0 {
 		cfg.configHash = b
 	}
+
+	if b, err := storage.Get("pin"); err == nil && len(b) > 0 && len(cfg.Pin) == 0 {
+		cfg.Pin = string(b)
+	}
+
+	if b, err := storage.Get("setupId"); err == nil && len(b) > 0 && len(cfg.SetupId) == 0 {
+		cfg.SetupId = string(b)
+	}
 }
 
-// save stores the id, version and config
+// save stores the id, version, config, pin and setup id
 func (cfg *Config) save(storage util.Storage) {
 	storage.Set("uuid", []byte(cfg.id))
 	storage.Set("version", []byte(fmt.Sprintf("%d", cfg.version)))
 	storage.Set("configHash", []byte(cfg.configHash))
+	storage.Set("pin", []byte(cfg.Pin))
+	storage.Set("setupId", []byte(cfg.SetupId))
 }
 
-// merge updates the StoragePath, Pin, Port and IP fields of the receiver from other.
+// merge updates the StoragePath, Pin, SetupId, Port, IP, EventInterval, connection timing and persistence fields of the receiver from other.
 func (cfg *Config) merge(other Config) {
 	if dir := other.StoragePath; len(dir) > 0 {
 		cfg.StoragePath = dir
@@ -107,6 +160,34 @@ func (cfg *Config) merge(other Config) {
 	if ip := other.IP; len(ip) > 0 {
 		cfg.IP = ip
 	}
+
+	if id := other.SetupId; len(id) > 0 {
+		cfg.SetupId = id
+	}
+
+	if interval := other.EventInterval; interval > 0 {
+		cfg.EventInterval = interval
+	}
+
+	if interval := other.KeepAliveInterval; interval > 0 {
+		cfg.KeepAliveInterval = interval
+	}
+
+	if period := other.TCPKeepAlivePeriod; period > 0 {
+		cfg.TCPKeepAlivePeriod = period
+	}
+
+	if timeout := other.WriteTimeout; timeout > 0 {
+		cfg.WriteTimeout = timeout
+	}
+
+	if other.PersistValues == true {
+		cfg.PersistValues = true
+	}
+
+	if delay := other.PersistDelay; delay > 0 {
+		cfg.PersistDelay = delay
+	}
 }
 
 // updateConfigHash updates configHash of the receiver and increments version
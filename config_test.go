@@ -62,3 +62,28 @@ func TestVersionUpdate(t *testing.T) {
 		t.Fatal(string(x))
 	}
 }
+
+func TestLoadPin(t *testing.T) {
+	storage, err := util.NewTempFileStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultConfig("Test")
+	cfg.Pin = "00102003"
+	cfg.save(storage)
+
+	cfg = defaultConfig("Test")
+	cfg.load(storage)
+	if is, want := cfg.Pin, "00102003"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Pin from config is preferred
+	cfg = defaultConfig("Test")
+	cfg.merge(Config{Pin: "34543210"})
+	cfg.load(storage)
+	if is, want := cfg.Pin, "34543210"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -61,6 +61,15 @@ func NewSecureClientSessionFromSharedKey(sharedKey [32]byte) (Cryptographer, err
 	return s, err
 }
 
+// NewSecureSessionFromKeys returns a session which encrypts outgoing data with encryptKey
+// and decrypts incoming data with decryptKey.
+func NewSecureSessionFromKeys(encryptKey, decryptKey [32]byte) Cryptographer {
+	return &secureSession{
+		encryptKey: encryptKey,
+		decryptKey: decryptKey,
+	}
+}
+
 // Encrypt return the encrypted data by splitting it into packets
 // [ length (2 bytes)] [ data ] [ auth (16 bytes)]
 func (s *secureSession) Encrypt(r io.Reader) (io.Reader, error) {
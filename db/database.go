@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"github.com/brutella/hc/util"
+	"sync"
 )
 
 // Database stores entities
@@ -19,10 +20,29 @@ type Database interface {
 
 	// Entities returns all entities
 	Entities() ([]Entity, error)
+
+	// PairingWithName returns the pairing of the client referenced by name
+	PairingWithName(name string) (Pairing, error)
+
+	// SavePairing saves a pairing in the database
+	SavePairing(pairing Pairing) error
+
+	// UpdatePairing changes the pairing of the client referenced by name with fn and saves it.
+	// Concurrent updates of the same pairing are serialized.
+	UpdatePairing(name string, fn func(pairing *Pairing)) error
+
+	// DeletePairing deletes the pairing of the client referenced by name
+	DeletePairing(name string)
+
+	// Pairings returns all pairings
+	Pairings() ([]Pairing, error)
 }
 
 type database struct {
 	storage util.Storage
+
+	// Serializes writes of pairings
+	mutex *sync.Mutex
 }
 
 // NewTempDatabase returns a temp database
@@ -43,7 +63,8 @@ func NewDatabase(path string) (Database, error) {
 
 // NewDatabaseWithStorage returns a database which uses the argument storage to store data.
 func NewDatabaseWithStorage(storage util.Storage) Database {
-	c := database{storage: storage}
+	c := database{storage: storage, mutex: &sync.Mutex{}}
+	c.migrateEntities()
 
 	return &c
 }
@@ -88,7 +109,6 @@ func (db *database) Entities() (es []Entity, err error) {
 
 func (db *database) entityForKey(key string) (e Entity, err error) {
 	var b []byte
-
 	if b, err = db.storage.Get(key); err == nil {
 		err = json.Unmarshal(b, &e)
 	}
@@ -96,6 +116,119 @@ func (db *database) entityForKey(key string) (e Entity, err error) {
 	return
 }
 
+// PairingWithName returns the pairing of the client with the specified name.
+func (db *database) PairingWithName(name string) (Pairing, error) {
+	return db.pairingForKey(toPairingKey(name))
+}
+
+// SavePairing stores the pairing as {name}.pairing.
+func (db *database) SavePairing(p Pairing) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.savePairing(p)
+}
+
+// UpdatePairing loads the pairing of the client with the specified name,
+// changes it with fn and stores it again.
+func (db *database) UpdatePairing(name string, fn func(p *Pairing)) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	p, err := db.pairingForKey(toPairingKey(name))
+	if err != nil {
+		return err
+	}
+
+	fn(&p)
+
+	return db.savePairing(p)
+}
+
+func (db *database) DeletePairing(name string) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.storage.Delete(toPairingKey(name))
+}
+
+func (db *database) Pairings() (ps []Pairing, err error) {
+	var p Pairing
+	var ks []string
+
+	if ks, err = db.storage.KeysWithSuffix(".pairing"); err == nil {
+		for _, k := range ks {
+			if p, err = db.pairingForKey(k); err != nil {
+				return nil, err
+			}
+			ps = append(ps, p)
+		}
+	}
+
+	return
+}
+
+func (db *database) savePairing(p Pairing) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return db.storage.Set(toPairingKey(p.Name), b)
+}
+
+func (db *database) pairingForKey(key string) (p Pairing, err error) {
+	var b []byte
+	if b, err = db.storage.Get(key); err == nil {
+		err = json.Unmarshal(b, &p)
+	}
+
+	return
+}
+
+// migrateEntities moves paired clients, which were stored as entities without
+// a private key, to pairings.
+// Clients stored without permission were paired before permissions were stored
+// and are treated as admins.
+func (db *database) migrateEntities() {
+	ks, err := db.storage.KeysWithSuffix(".entity")
+	if err != nil {
+		return
+	}
+
+	for _, k := range ks {
+		b, err := db.storage.Get(k)
+		if err != nil {
+			continue
+		}
+
+		e := struct {
+			Entity
+			Permission *byte
+		}{}
+		if err := json.Unmarshal(b, &e); err != nil || len(e.PrivateKey) > 0 {
+			continue
+		}
+
+		p := Pairing{
+			Name:       e.Name,
+			PublicKey:  e.PublicKey,
+			Permission: PermissionAdmin,
+		}
+		if e.Permission != nil {
+			p.Permission = *e.Permission
+		}
+
+		if err := db.SavePairing(p); err == nil {
+			db.storage.Delete(k)
+		}
+	}
+}
+
 func toEntityKey(s string) string {
 	return hex.EncodeToString([]byte(s)) + ".entity"
 }
+
+func toPairingKey(s string) string {
+	return hex.EncodeToString([]byte(s)) + ".pairing"
+}
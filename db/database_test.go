@@ -1,8 +1,13 @@
 package db
 
 import (
+	"github.com/brutella/hc/util"
+
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestLoadUndefinedEntity(t *testing.T) {
@@ -81,3 +86,110 @@ func TestGetEntities(t *testing.T) {
 		t.Fatal(x)
 	}
 }
+
+func TestPairing(t *testing.T) {
+	db, _ := NewTempDatabase()
+	db.SavePairing(NewPairing("User", []byte{0x01}, PermissionUser))
+
+	p, err := db.PairingWithName("User")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := p.IsAdmin(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := p.Created.IsZero(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := p.LastVerified.IsZero(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	db.DeletePairing("User")
+	if _, err := db.PairingWithName("User"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestUpdatePairingConcurrently(t *testing.T) {
+	db, _ := NewTempDatabase()
+	db.SavePairing(NewPairing("User", []byte{0x01}, PermissionUser))
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			db.UpdatePairing("User", func(p *Pairing) {
+				p.Nickname = fmt.Sprintf("iPad %d", i)
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			db.UpdatePairing("User", func(p *Pairing) {
+				p.LastVerified = time.Now()
+			})
+		}
+	}()
+	wg.Wait()
+
+	p, err := db.PairingWithName("User")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := p.Nickname, "iPad 49"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := p.LastVerified.IsZero(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if err := db.UpdatePairing("Unknown", func(p *Pairing) {}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestPairingsAndEntitiesAreSeparate(t *testing.T) {
+	db, _ := NewTempDatabase()
+	db.SaveEntity(NewEntity("Accessory", []byte{0x01}, []byte{0x02}))
+	db.SavePairing(NewPairing("Client", []byte{0x03}, PermissionAdmin))
+
+	if ps, _ := db.Pairings(); len(ps) != 1 || ps[0].Name != "Client" {
+		t.Fatal(ps)
+	}
+
+	if es, _ := db.Entities(); len(es) != 1 || es[0].Name != "Accessory" {
+		t.Fatal(es)
+	}
+}
+
+func TestMigrateLegacyEntities(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	storage.Set(toEntityKey("Legacy"), []byte(`{"Name":"Legacy","PublicKey":"AQ==","PrivateKey":null}`))
+	storage.Set(toEntityKey("User"), []byte(`{"Name":"User","PublicKey":"AQ==","PrivateKey":null,"Permission":0}`))
+	storage.Set(toEntityKey("Accessory"), []byte(`{"Name":"Accessory","PublicKey":"AQ==","PrivateKey":"Ag=="}`))
+	db := NewDatabaseWithStorage(storage)
+
+	if p, _ := db.PairingWithName("Legacy"); p.IsAdmin() == false {
+		t.Fatal(p.Permission)
+	}
+
+	if p, _ := db.PairingWithName("User"); p.IsAdmin() == true {
+		t.Fatal(p.Permission)
+	}
+
+	if _, err := db.EntityWithName("Legacy"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if _, err := db.EntityWithName("Accessory"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,14 @@ import (
 	"github.com/brutella/hc/util"
 )
 
+// Permissions of a pairing
+const (
+	PermissionUser  byte = 0x00
+	PermissionAdmin byte = 0x01
+)
+
+// Entity is a named key pair, e.g. the long-term keys of the accessory.
+// Paired clients are stored as Pairing.
 type Entity struct {
 	Name       string
 	PublicKey  []byte
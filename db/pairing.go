@@ -0,0 +1,41 @@
+package db
+
+import (
+	"time"
+)
+
+// Pairing is a client (controller) which paired with the accessory.
+type Pairing struct {
+	// Name is the pairing id of the client
+	Name string
+
+	// PublicKey is the long-term public key of the client
+	PublicKey []byte
+
+	// Permission of the client (PermissionUser or PermissionAdmin)
+	Permission byte
+
+	// Nickname is an optional user defined name of the client, e.g. "Kitchen iPad"
+	Nickname string
+
+	// Created is the time when the client paired
+	Created time.Time
+
+	// LastVerified is the time of the last successful pair verify, zero if never verified
+	LastVerified time.Time
+}
+
+// NewPairing returns a pairing with a name, public key and permission created now.
+func NewPairing(name string, publicKey []byte, permission byte) Pairing {
+	return Pairing{
+		Name:       name,
+		PublicKey:  publicKey,
+		Permission: permission,
+		Created:    time.Now(),
+	}
+}
+
+// IsAdmin returns true when the client is allowed to add and remove pairings.
+func (p Pairing) IsAdmin() bool {
+	return p.Permission == PermissionAdmin
+}
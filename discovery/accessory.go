@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"github.com/brutella/hc/accessory"
+
+	"github.com/brutella/dnssd"
+
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Status flags (sf) of an accessory
+const (
+	StatusNotPaired            int64 = 0x01 // accessory is not paired with any client
+	StatusNotConfiguredForWiFi int64 = 0x02 // accessory has not been configured to join a Wi-Fi network
+	StatusProblemDetected      int64 = 0x04 // accessory has detected a problem
+)
+
+// Accessory is a HomeKit accessory announced via DNS-SD.
+type Accessory struct {
+	Name string   // Service instance name
+	Host string   // Host name
+	IPs  []net.IP // IP addresses
+	Port int      // Port of the HAP server
+
+	ID           string                  // Device id (id)
+	Version      int64                   // Configuration number (c#)
+	State        int64                   // State number (s#)
+	Status       int64                   // Status flags (sf)
+	Category     accessory.AccessoryType // Accessory category (ci)
+	Model        string                  // Model name (md)
+	Protocol     string                  // Protocol version (pv)
+	FeatureFlags int64                   // Feature flags (ff)
+	SetupHash    string                  // Setup hash (sh), optional
+}
+
+// NewAccessory returns an accessory from the DNS-SD service srv.
+// Missing or invalid numbers in the txt records are zero.
+func NewAccessory(srv dnssd.Service) Accessory {
+	txt := srv.Text
+
+	return Accessory{
+		Name:         srv.Name,
+		Host:         srv.Host,
+		IPs:          srv.IPs,
+		Port:         srv.Port,
+		ID:           txt["id"],
+		Version:      parseInt(txt["c#"]),
+		State:        parseInt(txt["s#"]),
+		Status:       parseInt(txt["sf"]),
+		Category:     accessory.AccessoryType(parseInt(txt["ci"])),
+		Model:        txt["md"],
+		Protocol:     txt["pv"],
+		FeatureFlags: parseInt(txt["ff"]),
+		SetupHash:    txt["sh"],
+	}
+}
+
+// IsPaired returns true when the accessory is paired with a client.
+func (a Accessory) IsPaired() bool {
+	return a.Status&StatusNotPaired == 0
+}
+
+// Addr returns the address (host:port) of the accessory.
+// The first IP address is used, or the host name if no address is known.
+func (a Accessory) Addr() string {
+	host := a.Host
+	if len(a.IPs) > 0 {
+		host = a.IPs[0].String()
+	}
+
+	return net.JoinHostPort(host, fmt.Sprintf("%d", a.Port))
+}
+
+// equal returns true when a and b announce the same configuration and status.
+func (a Accessory) equal(b Accessory) bool {
+	return a.ID == b.ID &&
+		a.Version == b.Version &&
+		a.State == b.State &&
+		a.Status == b.Status &&
+		a.Category == b.Category &&
+		a.Model == b.Model &&
+		a.Protocol == b.Protocol &&
+		a.FeatureFlags == b.FeatureFlags &&
+		a.SetupHash == b.SetupHash &&
+		a.Port == b.Port
+}
+
+func parseInt(s string) int64 {
+	i, _ := strconv.ParseInt(s, 10, 64)
+	return i
+}
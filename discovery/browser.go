@@ -0,0 +1,123 @@
+package discovery
+
+import (
+	"github.com/brutella/dnssd"
+
+	"context"
+	"time"
+)
+
+// Type is the DNS-SD service type of HomeKit accessories.
+const Type = "_hap._tcp.local."
+
+// DefaultInterval is the default interval after which the browser queries the network again.
+const DefaultInterval = time.Minute
+
+// EventType is the type of a browse event.
+type EventType int
+
+// Browse event types
+const (
+	Added   EventType = iota // accessory appeared in the network
+	Updated                  // txt records of the accessory changed, e.g. configuration number or status flags
+	Removed                  // accessory disappeared from the network
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Added:
+		return "Added"
+	case Updated:
+		return "Updated"
+	case Removed:
+		return "Removed"
+	}
+
+	return "Unknown"
+}
+
+// Event is an accessory which was added, updated or removed.
+type Event struct {
+	Type      EventType
+	Accessory Accessory
+}
+
+// EventFunc is called for every browse event.
+type EventFunc func(ev Event)
+
+type lookupFunc func(ctx context.Context, service string, add dnssd.AddServiceFunc, rmv dnssd.RmvServiceFunc) error
+
+// Browser browses the local network for HomeKit accessories.
+type Browser struct {
+	// Interval after which the network is queried again.
+	// Changed txt records and accessories which disappeared without announcing it
+	// are detected within this interval.
+	Interval time.Duration
+
+	lookup lookupFunc
+}
+
+// NewBrowser returns a browser which queries the network every DefaultInterval.
+func NewBrowser() *Browser {
+	return &Browser{
+		Interval: DefaultInterval,
+		lookup:   dnssd.LookupType,
+	}
+}
+
+// Browse looks up accessories and calls fn when an accessory is added, updated or removed.
+// The method blocks until ctx is done or browsing fails.
+//
+// Accessories are identified by their service instance name. An accessory is updated
+// when its txt records change, e.g. when its configuration number (c#) is incremented
+// or when the accessory becomes unpaired (sf).
+func (b *Browser) Browse(ctx context.Context, fn EventFunc) error {
+	known := map[string]Accessory{}
+
+	for {
+		seen := map[string]bool{}
+
+		add := func(srv dnssd.Service) {
+			a := NewAccessory(srv)
+			seen[a.Name] = true
+
+			if old, ok := known[a.Name]; ok == false {
+				fn(Event{Added, a})
+			} else if old.equal(a) == false {
+				fn(Event{Updated, a})
+			}
+
+			known[a.Name] = a
+		}
+
+		rmv := func(srv dnssd.Service) {
+			if a, ok := known[srv.Name]; ok == true {
+				delete(known, srv.Name)
+				delete(seen, srv.Name)
+				fn(Event{Removed, a})
+			}
+		}
+
+		// Every lookup starts with an empty cache and sends a new query,
+		// which makes accessories respond with their current txt records.
+		lookupCtx, cancel := context.WithTimeout(ctx, b.Interval)
+		err := b.lookup(lookupCtx, Type, add, rmv)
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err != context.DeadlineExceeded {
+			return err
+		}
+
+		// Remove accessories which did not respond
+		for name, a := range known {
+			if seen[name] == false {
+				delete(known, name)
+				fn(Event{Removed, a})
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package discovery
+
+import (
+	"github.com/brutella/hc/accessory"
+
+	"github.com/brutella/dnssd"
+
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newService(name, version, status string) dnssd.Service {
+	return dnssd.Service{
+		Name: name,
+		Type: "_hap._tcp",
+		Host: "bridge",
+		IPs:  []net.IP{net.ParseIP("192.168.0.10")},
+		Port: 12345,
+		Text: map[string]string{
+			"pv": "1.0",
+			"id": "11:22:33:44:55:66",
+			"c#": version,
+			"s#": "1",
+			"sf": status,
+			"ff": "0",
+			"md": name,
+			"ci": "2",
+		},
+	}
+}
+
+func TestNewAccessory(t *testing.T) {
+	a := NewAccessory(newService("Bridge", "3", "1"))
+
+	if is, want := a.ID, "11:22:33:44:55:66"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := a.Version, int64(3); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := a.Category, accessory.TypeBridge; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := a.Protocol, "1.0"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := a.IsPaired(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := a.Addr(), "192.168.0.10:12345"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	// Services found by every lookup
+	rounds := [][]dnssd.Service{
+		{newService("Bridge", "1", "1"), newService("Lamp", "1", "0")},
+		{newService("Bridge", "2", "0"), newService("Lamp", "1", "0")},
+		{newService("Bridge", "2", "0")},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBrowser()
+	b.Interval = time.Millisecond
+	b.lookup = func(ctx context.Context, service string, add dnssd.AddServiceFunc, rmv dnssd.RmvServiceFunc) error {
+		if len(rounds) == 0 {
+			cancel()
+		} else {
+			for _, srv := range rounds[0] {
+				add(srv)
+			}
+			rounds = rounds[1:]
+		}
+
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	var events []string
+	err := b.Browse(ctx, func(ev Event) {
+		events = append(events, ev.Type.String()+" "+ev.Accessory.Name)
+	})
+
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	want := []string{"Added Bridge", "Added Lamp", "Updated Bridge", "Removed Lamp"}
+	if is := events; reflect.DeepEqual(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestBrowseRemove(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBrowser()
+	b.lookup = func(ctx context.Context, service string, add dnssd.AddServiceFunc, rmv dnssd.RmvServiceFunc) error {
+		srv := newService("Bridge", "1", "0")
+		add(srv)
+		rmv(srv)
+		cancel()
+
+		return ctx.Err()
+	}
+
+	var events []Event
+	b.Browse(ctx, func(ev Event) {
+		events = append(events, ev)
+	})
+
+	if is, want := len(events), 2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := events[1].Type, Removed; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,11 @@
+// Package discovery finds HomeKit accessories in the local network via DNS-SD.
+//
+// A browser looks up services of type _hap._tcp and decodes their txt records.
+// It calls a function when an accessory is added, when its configuration
+// or status changes and when it is removed.
+//
+//	b := discovery.NewBrowser()
+//	err := b.Browse(ctx, func(ev discovery.Event) {
+//	    log.Println(ev.Type, ev.Accessory.Name, ev.Accessory.ID)
+//	})
+package discovery
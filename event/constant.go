@@ -1,7 +1,16 @@
 package event
 
+import (
+	"net"
+)
+
 // DevicePaired is emitted when transport paired with a device (e.g. iOS client successfully paired with the accessory)
 type DevicePaired struct{}
 
 // DeviceUnpaired is emitted when pairing with a device is removed (e.g. iOS client removed the accessory from HomeKit)
 type DeviceUnpaired struct{}
+
+// ConnectionClosed is emitted when a client connection is closed or hijacked by the http server
+type ConnectionClosed struct {
+	Conn net.Conn
+}
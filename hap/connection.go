@@ -5,6 +5,7 @@ import (
 	"github.com/brutella/hc/crypto"
 	"github.com/brutella/hc/log"
 	"net"
+	"sync"
 	"time"
 
 	"bufio"
@@ -19,12 +20,21 @@ import (
 // pairing has been verified. After that the communication is encrypted.
 //
 // When the connection is closed, the related session is removed from the context.
+//
+// All writes to the connection are serialized. Event notifications are not written while
+// a request is being handled, but queued and written after the response.
 type Connection struct {
 	connection net.Conn
 	context    Context
 
 	// Used to buffer reads
 	readBuffer io.Reader
+
+	writeMutex   *sync.Mutex
+	writeTimeout time.Duration // no timeout when 0
+	active       bool          // true while a request is being handled
+	events       [][]byte      // events queued while active
+	closing      bool          // true when the connection is closed once inactive
 }
 
 // NewConnection returns a hap connection.
@@ -32,6 +42,7 @@ func NewConnection(connection net.Conn, context Context) *Connection {
 	conn := &Connection{
 		connection: connection,
 		context:    context,
+		writeMutex: &sync.Mutex{},
 	}
 
 	// Setup new session for the connection
@@ -91,11 +102,89 @@ func (con *Connection) DecryptedRead(b []byte) (int, error) {
 // Write writes bytes to the connection.
 // The written bytes are encrypted when possible.
 func (con *Connection) Write(b []byte) (int, error) {
+	con.writeMutex.Lock()
+	defer con.writeMutex.Unlock()
+
+	return con.write(b)
+}
+
+// WriteEvent writes an event notification to the connection.
+// While a request is being handled, the event is queued and written after the response.
+func (con *Connection) WriteEvent(b []byte) error {
+	con.writeMutex.Lock()
+	defer con.writeMutex.Unlock()
+
+	if con.active == true {
+		con.events = append(con.events, b)
+		return nil
+	}
+
+	_, err := con.write(b)
+	return err
+}
+
+// SetActive sets whether a request is currently being handled on the connection.
+// Queued events are written when the connection becomes inactive.
+func (con *Connection) SetActive(active bool) {
+	con.writeMutex.Lock()
+	defer con.writeMutex.Unlock()
+
+	con.active = active
+	if active == true {
+		return
+	}
+
+	if con.closing == true {
+		con.events = nil
+		con.Close()
+		return
+	}
+
+	events := con.events
+	con.events = nil
+	for _, b := range events {
+		if _, err := con.write(b); err != nil {
+			log.Debug.Println(err)
+			return
+		}
+	}
+}
+
+// CloseWhenInactive closes the connection right away when no request is being handled.
+// Otherwise the connection is closed after the response was written.
+// Queued events are discarded.
+func (con *Connection) CloseWhenInactive() {
+	con.writeMutex.Lock()
+	defer con.writeMutex.Unlock()
+
+	con.events = nil
+	if con.active == true {
+		con.closing = true
+		return
+	}
+
+	con.Close()
+}
+
+// write writes bytes to the connection. If writing fails or times out,
+// the connection is closed and the session removed.
+func (con *Connection) write(b []byte) (n int, err error) {
+	if con.writeTimeout > 0 {
+		con.connection.SetWriteDeadline(time.Now().Add(con.writeTimeout))
+	}
+
 	if con.getEncrypter() != nil {
-		return con.EncryptedWrite(b)
+		n, err = con.EncryptedWrite(b)
+	} else {
+		n, err = con.connection.Write(b)
 	}
 
-	return con.connection.Write(b)
+	if err != nil {
+		log.Debug.Println("Write failed:", err)
+		con.Close()
+	}
+
+	return n, err
 }
 
 // Read reads bytes from the connection. The read bytes are decrypted when possible.
@@ -0,0 +1,86 @@
+package hap
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConnectionQueuesEventsWhileActive(t *testing.T) {
+	rec := &recordConn{}
+	conn := NewConnection(rec, NewContextForSecuredDevice(nil))
+
+	conn.SetActive(true)
+	conn.WriteEvent([]byte("event"))
+	conn.Write([]byte("response"))
+
+	if is, want := len(rec.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	conn.SetActive(false)
+
+	writes := rec.Writes()
+	if is, want := len(writes), 2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := writes[0], "response"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := writes[1], "event"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestConnectionWritesEventsWhileIdle(t *testing.T) {
+	rec := &recordConn{}
+	conn := NewConnection(rec, NewContextForSecuredDevice(nil))
+
+	conn.WriteEvent([]byte("event"))
+
+	if is, want := len(rec.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestConnectionClosedWhenWriteFails(t *testing.T) {
+	rec := &recordConn{err: errors.New("write failed")}
+	context := NewContextForSecuredDevice(nil)
+	conn := NewConnection(rec, context)
+
+	if err := conn.WriteEvent([]byte("event")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if is, want := rec.closed, true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := len(context.ActiveConnections()), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestConnectionCloseWhenInactive(t *testing.T) {
+	rec := &recordConn{}
+	conn := NewConnection(rec, NewContextForSecuredDevice(nil))
+
+	conn.SetActive(true)
+	conn.WriteEvent([]byte("event"))
+	conn.CloseWhenInactive()
+
+	if is, want := rec.closed, false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	conn.SetActive(false)
+
+	if is, want := rec.closed, true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := len(rec.Writes()), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -13,7 +13,6 @@ import (
 
 	"io"
 	"io/ioutil"
-	"net"
 	"net/url"
 	"strings"
 )
@@ -30,9 +29,21 @@ func NewCharacteristicController(m *accessory.Container) *CharacteristicControll
 }
 
 // HandleGetCharacteristics handles a get characteristic request like `/characteristics?id=1.4,1.5`
-func (ctr *CharacteristicController) HandleGetCharacteristics(form url.Values, conn net.Conn) (io.Reader, error) {
-	var b bytes.Buffer
+//
+// Additional properties are returned when requested with the parameters
+// meta=1 (format, unit, min, max, step and max length), perms=1 (permissions),
+// type=1 (characteristic type) and ev=1 (events enabled for the session).
+//
+// When reading at least one characteristic failed, the returned json contains the status of
+// every characteristic and multiStatus is true.
+func (ctr *CharacteristicController) HandleGetCharacteristics(form url.Values, session hap.Session) (r io.Reader, multiStatus bool, err error) {
 	var chs []data.Characteristic
+	var statuses []int
+
+	meta := form.Get("meta") == "1"
+	perms := form.Get("perms") == "1"
+	typ := form.Get("type") == "1"
+	ev := form.Get("ev") == "1"
 
 	// id=1.4,1.5
 	paths := strings.Split(form.Get("id"), ",")
@@ -41,57 +52,151 @@ func (ctr *CharacteristicController) HandleGetCharacteristics(form url.Values, c
 			aid := to.Int64(ids[0]) // accessory id
 			iid := to.Int64(ids[1]) // instance id (= characteristic id)
 			c := data.Characteristic{AccessoryID: aid, CharacteristicID: iid}
+			status := hap.StatusSuccess
 			if ch := ctr.GetCharacteristic(aid, iid); ch != nil {
-				c.Value = ch.GetValueFromConnection(conn)
+				if value, err := ch.ReadValueFromConnection(session.Connection()); err != nil {
+					log.Info.Printf("Could not read characteristic with aid %d and iid %d: %v\n", aid, iid, err)
+					status = statusForError(err)
+				} else {
+					c.Value = value
+				}
+
+				if meta == true {
+					c.Format = ch.Format
+					c.Unit = ch.Unit
+					c.MinValue = ch.MinValue
+					c.MaxValue = ch.MaxValue
+					c.StepValue = ch.StepValue
+					c.MaxLen = ch.MaxLen
+				}
+
+				if perms == true {
+					c.Perms = ch.Perms
+				}
+
+				if typ == true {
+					c.Type = ch.Type
+				}
+
+				if ev == true {
+					c.Events = session.EventsEnabled(aid, iid)
+				}
 			} else {
-				c.Status = hap.StatusServiceCommunicationFailure
+				status = hap.StatusResourceDoesNotExist
+			}
+
+			if status != hap.StatusSuccess {
+				multiStatus = true
 			}
+
 			chs = append(chs, c)
+			statuses = append(statuses, status)
 		}
 	}
 
-	result, err := json.Marshal(&data.Characteristics{chs})
+	// Every characteristic contains a status in a multi-status response
+	if multiStatus == true {
+		for i := range chs {
+			chs[i].Status = statuses[i]
+		}
+	}
+
+	result, err := json.Marshal(&data.Characteristics{Characteristics: chs})
 	if err != nil {
-		log.Info.Panic(err)
+		return nil, false, err
 	}
 
-	b.Write(result)
-	return &b, err
+	return bytes.NewBuffer(result), multiStatus, nil
 }
 
 // HandleUpdateCharacteristics handles an update characteristic request. The bytes must represent
 // a data.Characteristics json.
-func (ctr *CharacteristicController) HandleUpdateCharacteristics(r io.Reader, conn net.Conn) error {
+//
+// Enabling or disabling events only affects the argument session.
+//
+// Characteristics which require a timed write are only updated, when the request
+// contains the process id of a timed write prepared on the session.
+// The method returns the status of every characteristic as json, when at least one
+// characteristic could not be updated or a write response was requested ("r": true).
+// Otherwise the returned reader is nil.
+func (ctr *CharacteristicController) HandleUpdateCharacteristics(r io.Reader, session hap.Session) (io.Reader, error) {
 	b, err := ioutil.ReadAll(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var chars data.Characteristics
 	err = json.Unmarshal(b, &chars)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.Debug.Println(string(b))
 
+	// A timed write request is only valid once
+	var timed bool
+	if chars.PID != nil {
+		timed = session.ExecuteTimedWrite(*chars.PID)
+	}
+
+	var chs []data.Characteristic
+	var respond bool
 	for _, c := range chars.Characteristics {
+		status := hap.StatusSuccess
+		var value interface{}
+
 		characteristic := ctr.GetCharacteristic(c.AccessoryID, c.CharacteristicID)
-		if characteristic == nil {
+		events, hasEvents := c.Events.(bool)
+
+		switch {
+		case characteristic == nil:
 			log.Info.Printf("Could not find characteristic with aid %d and iid %d\n", c.AccessoryID, c.CharacteristicID)
-			continue
-		}
+			status = hap.StatusResourceDoesNotExist
+		case chars.PID != nil && timed == false:
+			log.Info.Printf("Timed write %d for aid %d and iid %d is invalid or expired\n", *chars.PID, c.AccessoryID, c.CharacteristicID)
+			status = hap.StatusInvalidValueInRequest
+		case c.Value != nil && characteristic.RequiresTimedWrite() && timed == false:
+			log.Info.Printf("Characteristic with aid %d and iid %d requires a timed write\n", c.AccessoryID, c.CharacteristicID)
+			status = hap.StatusInvalidValueInRequest
+		case hasEvents == true && characteristic.SupportsEvents() == false:
+			log.Info.Printf("Characteristic with aid %d and iid %d does not support events\n", c.AccessoryID, c.CharacteristicID)
+			status = hap.StatusNotificationNotSupported
+		default:
+			if c.Value != nil {
+				if err := characteristic.WriteValueFromConnection(c.Value, session.Connection()); err != nil {
+					log.Info.Printf("Could not write characteristic with aid %d and iid %d: %v\n", c.AccessoryID, c.CharacteristicID, err)
+					status = statusForError(err)
+					break
+				}
+			}
+
+			if r, ok := c.Response.(bool); ok == true && r == true {
+				value = characteristic.WriteResponseFromConnection(c.Value, session.Connection())
+				respond = true
+			}
 
-		if c.Value != nil {
-			characteristic.UpdateValueFromConnection(c.Value, conn)
+			if hasEvents == true {
+				session.SetEventsEnabled(c.AccessoryID, c.CharacteristicID, events)
+			}
 		}
 
-		if events, ok := c.Events.(bool); ok == true {
-			characteristic.SetEventsEnabled(events)
+		if status != hap.StatusSuccess {
+			respond = true
 		}
+
+		chs = append(chs, data.Characteristic{AccessoryID: c.AccessoryID, CharacteristicID: c.CharacteristicID, Value: value, Status: status})
+	}
+
+	if respond == false {
+		return nil, nil
 	}
 
-	return err
+	result, err := json.Marshal(&data.Characteristics{Characteristics: chs})
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(result), nil
 }
 
 // GetCharacteristic returns the characteristic identified by the accessory id aid and characteristic id iid
@@ -109,3 +214,22 @@ func (ctr *CharacteristicController) GetCharacteristic(aid int64, iid int64) *ch
 	}
 	return nil
 }
+
+// statusForError returns the HAP status code for an error returned
+// when reading or writing a characteristic.
+func statusForError(err error) int {
+	switch err {
+	case nil:
+		return hap.StatusSuccess
+	case characteristic.ErrReadOnly:
+		return hap.StatusReadOnlyCharacteristic
+	case characteristic.ErrWriteOnly:
+		return hap.StatusWriteOnlyCharacteristic
+	case characteristic.ErrBusy:
+		return hap.StatusResourceBusy
+	case characteristic.ErrInvalidValue:
+		return hap.StatusInvalidValueInRequest
+	default:
+		return hap.StatusServiceCommunicationFailure
+	}
+}
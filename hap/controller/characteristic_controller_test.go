@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/brutella/hc/accessory"
 	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/hap"
 	"github.com/brutella/hc/hap/data"
 	"github.com/brutella/hc/service"
 
@@ -10,8 +11,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func idsString(accessoryID, characteristicID int64) url.Values {
@@ -38,12 +41,16 @@ func TestGetCharacteristic(t *testing.T) {
 	cid := a.Info.Name.GetID()
 	values := idsString(aid, cid)
 	controller := NewCharacteristicController(m)
-	res, err := controller.HandleGetCharacteristics(values, characteristic.TestConn)
+	res, multiStatus, err := controller.HandleGetCharacteristics(values, hap.NewSession(characteristic.TestConn))
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if multiStatus != false {
+		t.Fatal("unexpected multi-status response")
+	}
+
 	b, err := ioutil.ReadAll(res)
 
 	if err != nil {
@@ -112,13 +119,283 @@ func TestPutCharacteristic(t *testing.T) {
 	buffer.Write(b)
 
 	controller := NewCharacteristicController(m)
-	err = controller.HandleUpdateCharacteristics(&buffer, characteristic.TestConn)
+	res, err := controller.HandleUpdateCharacteristics(&buffer, hap.NewSession(characteristic.TestConn))
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if res != nil {
+		t.Fatal(res)
+	}
+
 	if is, want := a.Switch.On.GetValue(), true; is != want {
 		t.Fatalf("is=%v want=%v", is, want)
 	}
 }
+
+func TestEnableEventsPerSession(t *testing.T) {
+	info := accessory.Info{
+		Name:         "My Switch",
+		SerialNumber: "001",
+		Manufacturer: "Google",
+		Model:        "Bridge",
+	}
+
+	a := accessory.NewSwitch(info)
+
+	m := accessory.NewContainer()
+	m.AddAccessory(a.Accessory)
+
+	aid := a.Accessory.GetID()
+	cid := a.Switch.On.GetID()
+	char := data.Characteristic{AccessoryID: aid, CharacteristicID: cid, Events: true}
+	b, err := json.Marshal(data.Characteristics{Characteristics: []data.Characteristic{char}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := hap.NewSession(characteristic.TestConn)
+	other := hap.NewSession(characteristic.TestConn)
+
+	controller := NewCharacteristicController(m)
+	if _, err := controller.HandleUpdateCharacteristics(bytes.NewBuffer(b), session); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := session.EventsEnabled(aid, cid), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := other.EventsEnabled(aid, cid), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestTimedWrite(t *testing.T) {
+	info := accessory.Info{
+		Name:         "My Switch",
+		SerialNumber: "001",
+		Manufacturer: "Google",
+		Model:        "Bridge",
+	}
+
+	a := accessory.NewSwitch(info)
+	a.Switch.On.SetValue(false)
+	a.Switch.On.Perms = append(a.Switch.On.Perms, characteristic.PermTimedWrite)
+
+	m := accessory.NewContainer()
+	m.AddAccessory(a.Accessory)
+
+	char := data.Characteristic{AccessoryID: a.Accessory.GetID(), CharacteristicID: a.Switch.On.GetID(), Value: true}
+	chars := data.Characteristics{Characteristics: []data.Characteristic{char}}
+	b, err := json.Marshal(chars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := hap.NewSession(characteristic.TestConn)
+	controller := NewCharacteristicController(m)
+
+	// Untimed write is rejected
+	res, err := controller.HandleUpdateCharacteristics(bytes.NewBuffer(b), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res == nil {
+		t.Fatal("expected status response")
+	}
+
+	b, _ = ioutil.ReadAll(res)
+	if is, want := string(b), fmt.Sprintf(`{"characteristics":[{"aid":1,"iid":%d,"status":-70410}]}`, a.Switch.On.GetID()); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := a.Switch.On.GetValue(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Prepared write is accepted
+	var pid uint64 = 11122333
+	session.PrepareTimedWrite(pid, time.Second)
+	chars.PID = &pid
+	b, _ = json.Marshal(chars)
+
+	if res, err = controller.HandleUpdateCharacteristics(bytes.NewBuffer(b), session); err != nil {
+		t.Fatal(err)
+	}
+
+	if res != nil {
+		t.Fatal(res)
+	}
+
+	if is, want := a.Switch.On.GetValue(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Prepared write is only valid once
+	if res, _ = controller.HandleUpdateCharacteristics(bytes.NewBuffer(b), session); res == nil {
+		t.Fatal("expected status response")
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	info := accessory.Info{
+		Name:         "My Switch",
+		SerialNumber: "001",
+		Manufacturer: "Google",
+		Model:        "Bridge",
+	}
+
+	a := accessory.NewSwitch(info)
+	a.Switch.On.OnWriteResponse(func(conn net.Conn, c *characteristic.Characteristic, value interface{}) interface{} {
+		return "written"
+	})
+
+	m := accessory.NewContainer()
+	m.AddAccessory(a.Accessory)
+
+	char := data.Characteristic{AccessoryID: a.Accessory.GetID(), CharacteristicID: a.Switch.On.GetID(), Value: true, Response: true}
+	b, err := json.Marshal(data.Characteristics{Characteristics: []data.Characteristic{char}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller := NewCharacteristicController(m)
+	res, err := controller.HandleUpdateCharacteristics(bytes.NewBuffer(b), hap.NewSession(characteristic.TestConn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res == nil {
+		t.Fatal("expected write response")
+	}
+
+	b, _ = ioutil.ReadAll(res)
+	if is, want := string(b), fmt.Sprintf(`{"characteristics":[{"aid":1,"iid":%d,"value":"written","status":0}]}`, a.Switch.On.GetID()); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestGetCharacteristicStatus(t *testing.T) {
+	info := accessory.Info{
+		Name:         "My Switch",
+		SerialNumber: "001",
+		Manufacturer: "Google",
+		Model:        "Bridge",
+	}
+
+	a := accessory.NewSwitch(info)
+	a.Switch.On.OnValueRead(func(conn net.Conn, c *characteristic.Characteristic) (interface{}, error) {
+		return nil, characteristic.ErrCommunicationFailure
+	})
+
+	m := accessory.NewContainer()
+	m.AddAccessory(a.Accessory)
+
+	values := url.Values{}
+	values.Set("id", fmt.Sprintf("1.%d,1.%d,2.1", a.Info.Name.GetID(), a.Switch.On.GetID()))
+
+	controller := NewCharacteristicController(m)
+	res, multiStatus, err := controller.HandleGetCharacteristics(values, hap.NewSession(characteristic.TestConn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multiStatus != true {
+		t.Fatal("expected multi-status response")
+	}
+
+	b, _ := ioutil.ReadAll(res)
+	want := fmt.Sprintf(`{"characteristics":[{"aid":1,"iid":%d,"value":"My Switch","status":0},{"aid":1,"iid":%d,"status":-70402},{"aid":2,"iid":1,"status":-70409}]}`, a.Info.Name.GetID(), a.Switch.On.GetID())
+	if is := string(b); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestPutCharacteristicStatus(t *testing.T) {
+	info := accessory.Info{
+		Name:         "My Switch",
+		SerialNumber: "001",
+		Manufacturer: "Google",
+		Model:        "Bridge",
+	}
+
+	a := accessory.NewSwitch(info)
+	a.Switch.On.SetValue(false)
+	a.Switch.On.OnValueWrite(func(conn net.Conn, c *characteristic.Characteristic, value interface{}) error {
+		return characteristic.ErrBusy
+	})
+
+	m := accessory.NewContainer()
+	m.AddAccessory(a.Accessory)
+
+	chars := data.Characteristics{Characteristics: []data.Characteristic{
+		{AccessoryID: 1, CharacteristicID: a.Info.Name.GetID(), Value: "Other"},
+		{AccessoryID: 1, CharacteristicID: a.Switch.On.GetID(), Value: true},
+		{AccessoryID: 2, CharacteristicID: 1, Value: true},
+	}}
+	b, err := json.Marshal(chars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller := NewCharacteristicController(m)
+	res, err := controller.HandleUpdateCharacteristics(bytes.NewBuffer(b), hap.NewSession(characteristic.TestConn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res == nil {
+		t.Fatal("expected status response")
+	}
+
+	b, _ = ioutil.ReadAll(res)
+	want := fmt.Sprintf(`{"characteristics":[{"aid":1,"iid":%d,"status":-70404},{"aid":1,"iid":%d,"status":-70403},{"aid":2,"iid":1,"status":-70409}]}`, a.Info.Name.GetID(), a.Switch.On.GetID())
+	if is := string(b); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := a.Switch.On.GetValue(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestGetCharacteristicProperties(t *testing.T) {
+	info := accessory.Info{
+		Name:         "My Lightbulb",
+		SerialNumber: "001",
+		Manufacturer: "Google",
+		Model:        "Bridge",
+	}
+
+	a := accessory.NewLightbulb(info)
+	a.Lightbulb.Brightness.SetValue(50)
+
+	m := accessory.NewContainer()
+	m.AddAccessory(a.Accessory)
+
+	aid := a.Accessory.GetID()
+	iid := a.Lightbulb.Brightness.GetID()
+	session := hap.NewSession(characteristic.TestConn)
+	session.SetEventsEnabled(aid, iid, true)
+
+	values := idsString(aid, iid)
+	values.Set("meta", "1")
+	values.Set("perms", "1")
+	values.Set("type", "1")
+	values.Set("ev", "1")
+
+	controller := NewCharacteristicController(m)
+	res, _, err := controller.HandleGetCharacteristics(values, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := ioutil.ReadAll(res)
+	want := fmt.Sprintf(`{"characteristics":[{"aid":1,"iid":%d,"value":50,"ev":true,"type":"8","perms":["pr","pw","ev"],"format":"int32","unit":"percentage","minValue":0,"maxValue":100,"minStep":1}]}`, iid)
+	if is := string(b); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
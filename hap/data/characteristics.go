@@ -5,21 +5,25 @@ package data
 //  {
 //      "characteristics": [
 //          ...
-//      ]
+//      ] [, "pid": 11122333 ]
 //  }
 type Characteristics struct {
 	Characteristics []Characteristic `json:"characteristics"`
+
+	// PID contains the process id of a timed write, which was prepared before.
+	// The property is omited if not specified, which makes the payload smaller.
+	PID *uint64 `json:"pid,omitempty"`
 }
 
 // Characteristic implements json of format.
 //
 //  {
-//      "aid": 0, "iid": 1, "value": 10 [, "status": 0, "ev": true ]
+//      "aid": 0, "iid": 1, "value": 10 [, "status": 0, "ev": true, "r": true ]
 //  }
 type Characteristic struct {
 	AccessoryID      int64       `json:"aid"`
 	CharacteristicID int64       `json:"iid"`
-	Value            interface{} `json:"value"`
+	Value            interface{} `json:"value,omitempty"`
 
 	// Status contains the status code. Should be interpreted as integer.
 	// The property is omited if not specified, which makes the payload smaller.
@@ -28,4 +32,24 @@ type Characteristic struct {
 	// Events contains the events settings for a characteristic. Should be interpreted as boolean.
 	// The property is omited if not specified, which makes the payload smaller.
 	Events interface{} `json:"ev,omitempty"`
+
+	// Type contains the characteristic type and is only set when requested (type=1).
+	Type string `json:"type,omitempty"`
+
+	// Perms contains the characteristic permissions and is only set when requested (perms=1).
+	Perms []string `json:"perms,omitempty"`
+
+	// Format, Unit, MinValue, MaxValue, StepValue and MaxLen contain the
+	// characteristic metadata and are only set when requested (meta=1).
+	Format    string      `json:"format,omitempty"`
+	Unit      string      `json:"unit,omitempty"`
+	MinValue  interface{} `json:"minValue,omitempty"`
+	MaxValue  interface{} `json:"maxValue,omitempty"`
+	StepValue interface{} `json:"minStep,omitempty"`
+	MaxLen    int         `json:"maxLen,omitempty"`
+
+	// Response contains the write response flag. Should be interpreted as boolean.
+	// When true, the value is returned in the response of a write request.
+	// The property is omited if not specified, which makes the payload smaller.
+	Response interface{} `json:"r,omitempty"`
 }
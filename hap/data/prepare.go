@@ -0,0 +1,22 @@
+package data
+
+// Prepare implements json of format
+//
+//  {
+//      "ttl": 2500, "pid": 11122333
+//  }
+//
+// The ttl is specified in milliseconds.
+type Prepare struct {
+	TTL int64  `json:"ttl"`
+	PID uint64 `json:"pid"`
+}
+
+// Status implements json of format
+//
+//  {
+//      "status": 0
+//  }
+type Status struct {
+	Status int `json:"status"`
+}
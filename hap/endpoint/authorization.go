@@ -0,0 +1,55 @@
+package endpoint
+
+import (
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/log"
+
+	"encoding/json"
+	"net/http"
+)
+
+// StatusConnectionAuthorizationRequired is the HTTP status code for requests on connections,
+// which are not pair verified.
+const StatusConnectionAuthorizationRequired = 470
+
+// unsecuredPaths are the endpoints which can be requested before pair verify.
+var unsecuredPaths = map[string]bool{
+	"/pair-setup":  true,
+	"/pair-verify": true,
+	"/identify":    true,
+}
+
+// Authorization handles all requests and only forwards them to the wrapped handler,
+// when the session of the connection is pair verified or the endpoint doesn't require verification.
+// Otherwise it responds with 470 Connection Authorization Required.
+type Authorization struct {
+	http.Handler
+
+	handler http.Handler
+	context hap.Context
+}
+
+// NewAuthorization returns a handler which authorizes requests before forwarding them to h.
+func NewAuthorization(context hap.Context, h http.Handler) *Authorization {
+	return &Authorization{
+		handler: h,
+		context: context,
+	}
+}
+
+func (a *Authorization) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if unsecuredPaths[request.URL.Path] == false {
+		session := a.context.GetSessionForRequest(request)
+		if session == nil || session.Encrypter() == nil {
+			log.Info.Printf("%v %s %s is not authorized", request.RemoteAddr, request.Method, request.URL.Path)
+			b, _ := json.Marshal(data.Status{Status: hap.StatusInsufficientPrivileges})
+			response.Header().Set("Content-Type", hap.HTTPContentTypeHAPJson)
+			response.WriteHeader(StatusConnectionAuthorizationRequired)
+			response.Write(b)
+			return
+		}
+	}
+
+	a.handler.ServeHTTP(response, request)
+}
@@ -0,0 +1,51 @@
+package endpoint
+
+import (
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/crypto"
+	"github.com/brutella/hc/hap"
+
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorization(t *testing.T) {
+	context := hap.NewContextForSecuredDevice(nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/accessories", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/pair-verify", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewAuthorization(context, mux)
+
+	req := httptest.NewRequest("GET", "/accessories", nil)
+	session := hap.NewSession(characteristic.TestConn)
+	context.Set(context.GetConnectionKey(req), session)
+
+	// Not verified
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if is, want := w.Code, StatusConnectionAuthorizationRequired; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Unsecured endpoint
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/pair-verify", nil))
+	if is, want := w.Code, http.StatusOK; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Verified
+	cryptographer, _ := crypto.NewSecureSessionFromSharedKey([32]byte{})
+	session.SetCryptographer(cryptographer)
+	session.Decrypter() // the cryptographer is used after reading the next request
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if is, want := w.Code, http.StatusOK; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -2,7 +2,10 @@ package endpoint
 
 import (
 	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/data"
 	"github.com/brutella/hc/log"
+
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -35,6 +38,7 @@ func NewCharacteristics(context hap.Context, c hap.CharacteristicsHandler, mutex
 func (handler *Characteristics) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	var res io.Reader
 	var err error
+	var status = http.StatusOK
 
 	handler.mutex.Lock()
 	switch request.Method {
@@ -42,24 +46,32 @@ func (handler *Characteristics) ServeHTTP(response http.ResponseWriter, request
 		request.ParseForm()
 		log.Debug.Printf("%v GET /characteristics %v", request.RemoteAddr, request.Form)
 		session := handler.context.GetSessionForRequest(request)
-		conn := session.Connection()
-		res, err = handler.controller.HandleGetCharacteristics(request.Form, conn)
+		var multiStatus bool
+		res, multiStatus, err = handler.controller.HandleGetCharacteristics(request.Form, session)
+		if multiStatus == true {
+			status = http.StatusMultiStatus
+		}
 	case hap.MethodPUT:
 		log.Debug.Printf("%v PUT /characteristics", request.RemoteAddr)
 		session := handler.context.GetSessionForRequest(request)
-		conn := session.Connection()
-		err = handler.controller.HandleUpdateCharacteristics(request.Body, conn)
+		res, err = handler.controller.HandleUpdateCharacteristics(request.Body, session)
+		status = http.StatusMultiStatus
 	default:
 		log.Debug.Println("Cannot handle HTTP method", request.Method)
 	}
 	handler.mutex.Unlock()
 
 	if err != nil {
-		log.Info.Panic(err)
-		response.WriteHeader(http.StatusInternalServerError)
+		// The request could not be parsed
+		log.Info.Println(err)
+		b, _ := json.Marshal(data.Status{Status: hap.StatusInvalidValueInRequest})
+		response.Header().Set("Content-Type", hap.HTTPContentTypeHAPJson)
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write(b)
 	} else {
 		if res != nil {
 			response.Header().Set("Content-Type", hap.HTTPContentTypeHAPJson)
+			response.WriteHeader(status)
 			wr := hap.NewChunkedWriter(response, 2048)
 			b, _ := ioutil.ReadAll(res)
 			wr.Write(b)
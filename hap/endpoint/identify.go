@@ -1,24 +1,42 @@
 package endpoint
 
 import (
+	"github.com/brutella/hc/db"
 	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/hap/pair"
 	"github.com/brutella/hc/log"
+
+	"encoding/json"
 	"net/http"
 )
 
 // Identify handles the unencrypted /identify endpoint by calling IdentifyAccessory() on the IdentifyHandler
+//
+// The accessory can only be identified while it is not paired.
 type Identify struct {
 	http.Handler
-	handler hap.IdentifyHandler
+	handler  hap.IdentifyHandler
+	database db.Database
 }
 
 // NewIdentify returns an object which serves the /identify endpoint
-func NewIdentify(h hap.IdentifyHandler) *Identify {
-	return &Identify{handler: h}
+func NewIdentify(h hap.IdentifyHandler, database db.Database) *Identify {
+	return &Identify{handler: h, database: database}
 }
 
 func (i *Identify) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	log.Debug.Printf("%v POST /identify", request.RemoteAddr)
+
+	if pair.IsPaired(i.database) == true {
+		log.Info.Println("Identify is not allowed while paired")
+		b, _ := json.Marshal(data.Status{Status: hap.StatusInsufficientPrivileges})
+		response.Header().Set("Content-Type", hap.HTTPContentTypeHAPJson)
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write(b)
+		return
+	}
+
 	i.handler.IdentifyAccessory()
 	response.WriteHeader(http.StatusNoContent)
 }
@@ -1,6 +1,7 @@
 package endpoint
 
 import (
+	"github.com/brutella/hc/crypto"
 	"github.com/brutella/hc/db"
 	"github.com/brutella/hc/event"
 	"github.com/brutella/hc/hap"
@@ -19,6 +20,7 @@ import (
 // This is required to support simultaneous pairing connections.
 //
 // When pairing finished, the DevicePaired event is sent using an event emitter.
+// A transient pair setup doesn't pair the client but switches to a secure session.
 type PairSetup struct {
 	http.Handler
 
@@ -26,15 +28,19 @@ type PairSetup struct {
 	database db.Database
 	context  hap.Context
 	emitter  event.Emitter
+	limiter  *pair.SetupLimiter
+	verifier *pair.VerifierStore
 }
 
 // NewPairSetup returns a new handler for pairing endpoint
-func NewPairSetup(context hap.Context, device hap.SecuredDevice, database db.Database, emitter event.Emitter) *PairSetup {
+func NewPairSetup(context hap.Context, device hap.SecuredDevice, database db.Database, limiter *pair.SetupLimiter, verifier *pair.VerifierStore, emitter event.Emitter) *PairSetup {
 	endpoint := PairSetup{
 		device:   device,
 		database: database,
 		context:  context,
 		emitter:  emitter,
+		limiter:  limiter,
+		verifier: verifier,
 	}
 
 	return &endpoint
@@ -54,7 +60,7 @@ func (endpoint *PairSetup) ServeHTTP(response http.ResponseWriter, request *http
 	if ctrl == nil {
 		log.Debug.Println("Create new pair setup controller")
 
-		if ctrl, err = pair.NewSetupServerController(endpoint.device, endpoint.database); err != nil {
+		if ctrl, err = pair.NewSetupServerController(endpoint.device, endpoint.database, endpoint.limiter, endpoint.verifier); err != nil {
 			log.Info.Panic(err)
 		}
 
@@ -74,6 +80,15 @@ func (endpoint *PairSetup) ServeHTTP(response http.ResponseWriter, request *http
 		// Send event when key exchange is done
 		b := out.GetByte(pair.TagSequence)
 		switch pair.PairStepType(b) {
+		case pair.PairStepVerifyResponse:
+			if code := out.GetByte(pair.TagErrCode); code != pair.ErrCodeNo.Byte() {
+				return
+			}
+
+			if setup, ok := ctrl.(*pair.SetupServerController); ok == true && setup.Transient() == true {
+				log.Debug.Println("Setup secure session after transient pair setup")
+				session.SetCryptographer(crypto.NewSecureSessionFromKeys(setup.SessionKeys()))
+			}
 		case pair.PairStepKeyExchangeResponse:
 			endpoint.emitter.Emit(event.DevicePaired{})
 		}
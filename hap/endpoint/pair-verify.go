@@ -62,13 +62,19 @@ func (endpoint *PairVerify) ServeHTTP(response http.ResponseWriter, request *htt
 		io.Copy(response, out.BytesBuffer())
 
 		// When key verification is done, switch to a secure session
-		// based on the negotiated shared session key
+		// based on the negotiated shared session key, unless verification failed
 		b := out.GetByte(pair.TagSequence)
 		switch pair.VerifyStepType(b) {
 		case pair.VerifyStepFinishResponse:
+			if code := out.GetByte(pair.TagErrCode); code != pair.ErrCodeNo.Byte() {
+				log.Info.Println("Pair verify failed with error", code)
+				return
+			}
+
 			if secSession, err = crypto.NewSecureSessionFromSharedKey(ctlr.SharedKey()); err == nil {
 				log.Debug.Println("Setup secure session")
 				session.SetCryptographer(secSession)
+				session.SetUsername(ctlr.Username())
 			} else {
 				log.Info.Panic("Could not setup secure session.", err)
 			}
@@ -1,6 +1,7 @@
 package endpoint
 
 import (
+	"github.com/brutella/hc/db"
 	"github.com/brutella/hc/event"
 	"github.com/brutella/hc/hap"
 	"github.com/brutella/hc/hap/pair"
@@ -12,17 +13,22 @@ import (
 
 // Pairing handles the /pairings endpoint.
 //
-// This endpoint is not session based and the same for all connections.
+// Pairings can only be added and removed by clients which verified
+// their pairing as admin in the current session.
 type Pairing struct {
 	http.Handler
 
+	context    hap.Context
+	database   db.Database
 	controller *pair.PairingController
 	emitter    event.Emitter
 }
 
 // NewPairing returns a new handler for pairing enpdoint
-func NewPairing(controller *pair.PairingController, emitter event.Emitter) *Pairing {
+func NewPairing(context hap.Context, database db.Database, controller *pair.PairingController, emitter event.Emitter) *Pairing {
 	endpoint := Pairing{
+		context:    context,
+		database:   database,
 		controller: controller,
 		emitter:    emitter,
 	}
@@ -38,8 +44,16 @@ func (endpoint *Pairing) ServeHTTP(response http.ResponseWriter, request *http.R
 	var in util.Container
 	var out util.Container
 
+	key := endpoint.context.GetConnectionKey(request)
+	session, ok := endpoint.context.Get(key).(hap.Session)
+	if ok == false {
+		log.Info.Println("No session for", request.RemoteAddr)
+		response.WriteHeader(StatusConnectionAuthorizationRequired)
+		return
+	}
+
 	if in, err = util.NewTLV8ContainerFromReader(request.Body); err == nil {
-		out, err = endpoint.controller.Handle(in)
+		out, err = endpoint.controller.Handle(in, session.Username())
 	}
 
 	if err != nil {
@@ -48,10 +62,15 @@ func (endpoint *Pairing) ServeHTTP(response http.ResponseWriter, request *http.R
 	} else {
 		io.Copy(response, out.BytesBuffer())
 
+		if out.GetByte(pair.TagErrCode) != pair.ErrCodeNo.Byte() {
+			return
+		}
+
 		// Send events based on pairing method type
 		b := in.GetByte(pair.TagPairingMethod)
 		switch pair.PairMethodType(b) {
 		case pair.PairingMethodDelete: // pairing removed
+			hap.CloseUnpairedSessions(endpoint.context, endpoint.database)
 			endpoint.emitter.Emit(event.DeviceUnpaired{})
 
 		case pair.PairingMethodAdd: // pairing added
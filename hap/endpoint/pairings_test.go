@@ -0,0 +1,23 @@
+package endpoint
+
+import (
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/event"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/pair"
+
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPairingsWithoutSession(t *testing.T) {
+	database, _ := db.NewTempDatabase()
+	context := hap.NewContextForSecuredDevice(nil)
+	handler := NewPairing(context, database, pair.NewPairingController(database), event.NewEmitter())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/pairings", nil))
+	if is, want := w.Code, StatusConnectionAuthorizationRequired; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,67 @@
+package endpoint
+
+import (
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/log"
+
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Prepare handles the /prepare endpoint to prepare a timed write.
+//
+// This endpoint is session based. The prepared timed write is stored in the session
+// and is only valid for the next write request on the same connection.
+type Prepare struct {
+	http.Handler
+
+	context hap.Context
+}
+
+// NewPrepare returns a new handler for prepare endpoint
+func NewPrepare(context hap.Context) *Prepare {
+	handler := Prepare{
+		context: context,
+	}
+
+	return &handler
+}
+
+func (handler *Prepare) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if request.Method != hap.MethodPUT {
+		log.Debug.Println("Cannot handle HTTP method", request.Method)
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	log.Debug.Printf("%v PUT /prepare", request.RemoteAddr)
+	response.Header().Set("Content-Type", hap.HTTPContentTypeHAPJson)
+
+	var req data.Prepare
+	status := data.Status{Status: hap.StatusSuccess}
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		log.Info.Println(err)
+		status.Status = hap.StatusInvalidValueInRequest
+	} else if req.TTL <= 0 {
+		log.Info.Println("Invalid ttl", req.TTL)
+		status.Status = hap.StatusInvalidValueInRequest
+	} else {
+		session := handler.context.GetSessionForRequest(request)
+		session.PrepareTimedWrite(req.PID, time.Duration(req.TTL)*time.Millisecond)
+	}
+
+	b, err := json.Marshal(status)
+	if err != nil {
+		log.Info.Println(err)
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if status.Status != hap.StatusSuccess {
+		response.WriteHeader(http.StatusBadRequest)
+	}
+
+	response.Write(b)
+}
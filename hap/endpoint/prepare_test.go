@@ -0,0 +1,72 @@
+package endpoint
+
+import (
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/hap"
+
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	context := hap.NewContextForSecuredDevice(nil)
+	handler := NewPrepare(context)
+
+	req := httptest.NewRequest("PUT", "/prepare", strings.NewReader(`{"ttl":1000,"pid":1}`))
+	session := hap.NewSession(characteristic.TestConn)
+	context.Set(context.GetConnectionKey(req), session)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if is, want := w.Code, http.StatusOK; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := session.ExecuteTimedWrite(1), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestPrepareMethodNotAllowed(t *testing.T) {
+	handler := NewPrepare(hap.NewContextForSecuredDevice(nil))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/prepare", nil))
+	if is, want := w.Code, http.StatusMethodNotAllowed; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestPrepareInvalidRequest(t *testing.T) {
+	context := hap.NewContextForSecuredDevice(nil)
+	handler := NewPrepare(context)
+
+	bodies := []string{
+		`{"ttl":0,"pid":1}`,
+		`{"ttl":-1000,"pid":1}`,
+		`{"pid":1}`,
+		`{"ttl":`,
+	}
+
+	for _, body := range bodies {
+		req := httptest.NewRequest("PUT", "/prepare", strings.NewReader(body))
+		session := hap.NewSession(characteristic.TestConn)
+		context.Set(context.GetConnectionKey(req), session)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if is, want := w.Code, http.StatusBadRequest; is != want {
+			t.Fatalf("is=%v want=%v", is, want)
+		}
+
+		if is, want := w.Body.String(), `{"status":-70410}`; is != want {
+			t.Fatalf("is=%v want=%v", is, want)
+		}
+
+		if is, want := session.ExecuteTimedWrite(1), false; is != want {
+			t.Fatalf("is=%v want=%v", is, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package hap
+
+import (
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/log"
+	"net"
+	"sync"
+	"time"
+)
+
+// eventCoalesceDelay is the time to wait for other value changes before an
+// event notification is sent. Changes within this time are sent as one notification.
+const eventCoalesceDelay = 20 * time.Millisecond
+
+// EventScheduler sends value changes of characteristics as event notifications to a connection.
+//
+// Value changes are coalesced into one notification and a characteristic is sent at most
+// once per interval. When the value changes again before it was sent, only the latest value is sent.
+// HAP recommends an interval of 1 second.
+type EventScheduler struct {
+	conn     net.Conn
+	interval time.Duration
+
+	pending []*scheduledEvent
+	sent    map[characteristicKey]time.Time
+	timer   *time.Timer
+	mutex   *sync.Mutex
+}
+
+type scheduledEvent struct {
+	key   characteristicKey
+	value interface{}
+	due   time.Time
+}
+
+// NewEventScheduler returns a scheduler which sends events to conn.
+func NewEventScheduler(conn net.Conn, interval time.Duration) *EventScheduler {
+	return &EventScheduler{
+		conn:     conn,
+		interval: interval,
+		sent:     map[characteristicKey]time.Time{},
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// Schedule schedules an event for the value of the characteristic with accessory id aid
+// and characteristic id iid. When immediate is true, the event is sent right away
+// without a rate limit (e.g. for button presses).
+func (s *EventScheduler) Schedule(aid, iid int64, value interface{}, immediate bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	key := characteristicKey{aid, iid}
+
+	due := now.Add(eventCoalesceDelay)
+	if last, ok := s.sent[key]; ok == true && last.Add(s.interval).After(due) {
+		due = last.Add(s.interval)
+	}
+
+	if immediate == true {
+		due = now
+	}
+
+	// Replace the value of a pending event for the same characteristic
+	var ev *scheduledEvent
+	for _, p := range s.pending {
+		if p.key == key {
+			ev = p
+			break
+		}
+	}
+
+	if ev == nil {
+		ev = &scheduledEvent{key: key, due: due}
+		s.pending = append(s.pending, ev)
+	} else if due.Before(ev.due) {
+		ev.due = due
+	}
+	ev.value = value
+
+	if immediate == true {
+		s.flush()
+	} else {
+		s.resetTimer()
+	}
+}
+
+// Stop stops the scheduler and discards pending events.
+func (s *EventScheduler) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.pending = nil
+}
+
+func (s *EventScheduler) fire() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.flush()
+}
+
+// flush sends all pending events which are due in one notification.
+func (s *EventScheduler) flush() {
+	now := time.Now()
+
+	var chs []data.Characteristic
+	var remaining []*scheduledEvent
+	for _, ev := range s.pending {
+		if ev.due.After(now) {
+			remaining = append(remaining, ev)
+			continue
+		}
+
+		chs = append(chs, data.Characteristic{AccessoryID: ev.key.aid, CharacteristicID: ev.key.iid, Value: ev.value})
+		s.sent[ev.key] = now
+	}
+	s.pending = remaining
+
+	if len(chs) > 0 {
+		s.send(chs)
+	}
+
+	s.resetTimer()
+}
+
+// resetTimer fires the timer when the next pending event is due.
+func (s *EventScheduler) resetTimer() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	if len(s.pending) == 0 {
+		return
+	}
+
+	next := s.pending[0].due
+	for _, ev := range s.pending {
+		if ev.due.Before(next) {
+			next = ev.due
+		}
+	}
+
+	s.timer = time.AfterFunc(time.Until(next), s.fire)
+}
+
+func (s *EventScheduler) send(chs []data.Characteristic) {
+	b, err := NewCharacteristicsNotification(chs)
+	if err != nil {
+		log.Info.Println(err)
+		return
+	}
+
+	log.Debug.Printf("%s <- %s", s.conn.RemoteAddr(), string(b))
+	if err := writeNotification(s.conn, b); err != nil {
+		log.Debug.Println(err)
+	}
+}
@@ -0,0 +1,104 @@
+package hap
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordConn records the data written to it.
+// Writes fail with err when set.
+type recordConn struct {
+	net.Conn
+	writes []string
+	err    error
+	closed bool
+	mutex  sync.Mutex
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.writes = append(c.writes, string(b))
+	return len(b), nil
+}
+
+func (c *recordConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *recordConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (c *recordConn) Writes() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string{}, c.writes...)
+}
+
+func TestEventSchedulerCoalesce(t *testing.T) {
+	conn := &recordConn{}
+	s := NewEventScheduler(conn, time.Second)
+	defer s.Stop()
+
+	s.Schedule(1, 2, 10, false)
+	s.Schedule(1, 3, 20, false)
+
+	time.Sleep(100 * time.Millisecond)
+
+	writes := conn.Writes()
+	if is, want := len(writes), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := strings.Contains(writes[0], `"iid":2`) && strings.Contains(writes[0], `"iid":3`), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestEventSchedulerRateLimit(t *testing.T) {
+	conn := &recordConn{}
+	s := NewEventScheduler(conn, 200*time.Millisecond)
+	defer s.Stop()
+
+	s.Schedule(1, 2, 10, false)
+	time.Sleep(50 * time.Millisecond)
+	s.Schedule(1, 2, 11, false)
+	s.Schedule(1, 2, 12, false)
+	time.Sleep(50 * time.Millisecond)
+
+	if is, want := len(conn.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+
+	writes := conn.Writes()
+	if is, want := len(writes), 2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := strings.Contains(writes[1], `"value":12`), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestEventSchedulerImmediate(t *testing.T) {
+	conn := &recordConn{}
+	s := NewEventScheduler(conn, time.Second)
+	defer s.Stop()
+
+	s.Schedule(1, 2, 0, true)
+	s.Schedule(1, 2, 1, true)
+
+	if is, want := len(conn.Writes()), 2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
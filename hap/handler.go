@@ -3,7 +3,6 @@ package hap
 import (
 	"github.com/brutella/hc/util"
 	"io"
-	"net"
 	"net/url"
 )
 
@@ -16,6 +15,9 @@ type ContainerHandler interface {
 type PairVerifyHandler interface {
 	ContainerHandler
 	SharedKey() [32]byte
+
+	// Username returns the name of the verified client
+	Username() string
 }
 
 // A AccessoriesHandler returns a list of accessories as json.
@@ -24,9 +26,18 @@ type AccessoriesHandler interface {
 }
 
 // A CharacteristicsHandler handles get and update characteristic.
+// The session is used to access the connection and the characteristics
+// for which the client enabled events.
+//
+// HandleGetCharacteristics returns a json with the status of every characteristic and multiStatus
+// is true, when reading at least one characteristic failed.
+//
+// HandleUpdateCharacteristics returns a json with the status of every characteristic,
+// when updating at least one characteristic failed or a write response was requested.
+// Otherwise the returned reader is nil.
 type CharacteristicsHandler interface {
-	HandleGetCharacteristics(url.Values, net.Conn) (io.Reader, error)
-	HandleUpdateCharacteristics(io.Reader, net.Conn) error
+	HandleGetCharacteristics(url.Values, Session) (r io.Reader, multiStatus bool, err error)
+	HandleUpdateCharacteristics(io.Reader, Session) (io.Reader, error)
 }
 
 // IdentifyHandler calls Identify() on accessories.
@@ -14,6 +14,7 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -24,6 +25,18 @@ type Config struct {
 	Device    hap.SecuredDevice
 	Mutex     *sync.Mutex
 	Emitter   event.Emitter
+
+	// Limits pair setup attempts
+	SetupLimiter *pair.SetupLimiter
+
+	// Stores the verifier of split pair setups
+	VerifierStore *pair.VerifierStore
+
+	// Period of TCP keep-alive probes; 0 uses the default keep-alive settings
+	TCPKeepAlivePeriod time.Duration
+
+	// Maximum duration of a write to a connection; 0 means no timeout
+	WriteTimeout time.Duration
 }
 
 type Server struct {
@@ -40,6 +53,11 @@ type Server struct {
 	hapListener *hap.TCPListener
 
 	emitter event.Emitter
+
+	setupLimiter       *pair.SetupLimiter
+	verifierStore      *pair.VerifierStore
+	tcpKeepAlivePeriod time.Duration
+	writeTimeout       time.Duration
 }
 
 // NewServer returns a server
@@ -63,6 +81,11 @@ func NewServer(c Config) *Server {
 		listener:  ln.(*net.TCPListener),
 		port:      port,
 		emitter:   c.Emitter,
+
+		setupLimiter:       c.SetupLimiter,
+		verifierStore:      c.VerifierStore,
+		tcpKeepAlivePeriod: c.TCPKeepAlivePeriod,
+		writeTimeout:       c.WriteTimeout,
 	}
 
 	s.setupEndpoints()
@@ -79,7 +102,9 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 		// Stop listener
 		s.hapListener.Close()
 	}()
-	return s.listenAndServe(s.addrString(), s.Mux, s.context)
+	// Only pair verified connections can access secured endpoints
+	handler := endpoint.NewAuthorization(s.context, s.Mux)
+	return s.listenAndServe(s.addrString(), handler, s.context)
 }
 
 func (s *Server) Port() string {
@@ -88,13 +113,34 @@ func (s *Server) Port() string {
 
 // listenAndServe returns a http.Server to listen on a specific address
 func (s *Server) listenAndServe(addr string, handler http.Handler, context hap.Context) error {
-	server := http.Server{Addr: addr, Handler: handler}
+	server := http.Server{Addr: addr, Handler: handler, ConnState: s.connStateChanged}
 	// Use a TCPListener
 	listener := hap.NewTCPListener(s.listener, context)
+	listener.KeepAlivePeriod = s.tcpKeepAlivePeriod
+	listener.WriteTimeout = s.writeTimeout
 	s.hapListener = listener
 	return server.Serve(listener)
 }
 
+// connStateChanged makes sure that events are not written to a connection
+// while a response is pending. When a connection is closed or hijacked,
+// the ConnectionClosed event is emitted.
+func (s *Server) connStateChanged(conn net.Conn, state http.ConnState) {
+	if c, ok := conn.(*hap.Connection); ok == true {
+		switch state {
+		case http.StateActive:
+			c.SetActive(true)
+		case http.StateIdle, http.StateHijacked:
+			c.SetActive(false)
+		}
+	}
+
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		s.emitter.Emit(event.ConnectionClosed{Conn: conn})
+	}
+}
+
 func (s *Server) addrString() string {
 	return ":" + s.port
 }
@@ -105,10 +151,11 @@ func (s *Server) setupEndpoints() {
 	characteristicsController := controller.NewCharacteristicController(s.container)
 	pairingController := pair.NewPairingController(s.database)
 
-	s.Mux.Handle("/pair-setup", endpoint.NewPairSetup(s.context, s.device, s.database, s.emitter))
+	s.Mux.Handle("/pair-setup", endpoint.NewPairSetup(s.context, s.device, s.database, s.setupLimiter, s.verifierStore, s.emitter))
 	s.Mux.Handle("/pair-verify", endpoint.NewPairVerify(s.context, s.database))
 	s.Mux.Handle("/accessories", endpoint.NewAccessories(containerController, s.mutex))
 	s.Mux.Handle("/characteristics", endpoint.NewCharacteristics(s.context, characteristicsController, s.mutex))
-	s.Mux.Handle("/pairings", endpoint.NewPairing(pairingController, s.emitter))
-	s.Mux.Handle("/identify", endpoint.NewIdentify(containerController))
+	s.Mux.Handle("/prepare", endpoint.NewPrepare(s.context))
+	s.Mux.Handle("/pairings", endpoint.NewPairing(s.context, s.database, pairingController, s.emitter))
+	s.Mux.Handle("/identify", endpoint.NewIdentify(containerController, s.database))
 }
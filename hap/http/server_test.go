@@ -0,0 +1,74 @@
+package http
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/event"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/pair"
+	"github.com/brutella/hc/util"
+
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type closedListener chan net.Conn
+
+func (l closedListener) Handle(ev interface{}) {
+	if e, ok := ev.(event.ConnectionClosed); ok == true {
+		l <- e.Conn
+	}
+}
+
+func TestConnectionClosedEvent(t *testing.T) {
+	storage, err := util.NewTempFileStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database := db.NewDatabaseWithStorage(storage)
+	device, err := hap.NewSecuredDevice("Test", "001-02-003", database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(closedListener, 1)
+	emitter := event.NewEmitter()
+	emitter.AddListener(closed)
+
+	s := NewServer(Config{
+		Context:       hap.NewContextForSecuredDevice(device),
+		Database:      database,
+		Container:     accessory.NewContainer(),
+		Device:        device,
+		Mutex:         &sync.Mutex{},
+		Emitter:       emitter,
+		SetupLimiter:  pair.NewSetupLimiter(storage),
+		VerifierStore: pair.NewVerifierStore(storage),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.ListenAndServe(ctx)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+s.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make the server handle the connection
+	conn.Write([]byte("GET /accessories HTTP/1.1\r\nHost: test\r\n\r\n"))
+	conn.Close()
+
+	select {
+	case c := <-closed:
+		if _, ok := c.(*hap.Connection); ok == false {
+			t.Fatalf("unexpected connection %T", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+}
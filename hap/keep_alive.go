@@ -4,7 +4,6 @@ import (
 	"bytes"
 	gocontext "context"
 	"github.com/brutella/hc/log"
-	"io/ioutil"
 	"time"
 )
 
@@ -38,17 +37,19 @@ func (k *KeepAlive) Start(ctx gocontext.Context) {
 	}
 }
 
+// sendKeepAlive sends a keep alive notification to all connections, which verified the pairing.
+// Connections in pair setup or pair verify don't get notifications.
 func (k *KeepAlive) sendKeepAlive() {
 	conns := k.context.ActiveConnections()
-	var empty = new(bytes.Buffer)
 	for _, conn := range conns {
-		resp := NewNotification(empty)
+		if session := k.context.GetSessionForConnection(conn); session == nil || session.Encrypter() == nil {
+			continue
+		}
 
-		var buffer = new(bytes.Buffer)
-		resp.Write(buffer)
-		bytes, _ := ioutil.ReadAll(buffer)
-		bytes = FixProtocolSpecifier(bytes)
-		log.Debug.Printf("Keep alive %s <- %s", conn.RemoteAddr(), string(bytes))
-		conn.Write(bytes)
+		b := NewNotification(new(bytes.Buffer))
+		log.Debug.Printf("Keep alive %s <- %s", conn.RemoteAddr(), string(b))
+		if err := writeNotification(conn, b); err != nil {
+			log.Debug.Printf("Keep alive %s failed: %v", conn.RemoteAddr(), err)
+		}
 	}
 }
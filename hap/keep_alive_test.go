@@ -0,0 +1,31 @@
+package hap
+
+import (
+	"github.com/brutella/hc/crypto"
+
+	"testing"
+)
+
+func TestKeepAliveOnlyForVerifiedConnections(t *testing.T) {
+	context := NewContextForSecuredDevice(nil)
+	k := NewKeepAlive(0, context)
+
+	unverified := &recordConn{}
+	NewConnection(unverified, context)
+
+	verified := &recordConn{}
+	conn := NewConnection(verified, context)
+	session := context.GetSessionForConnection(conn)
+	session.SetCryptographer(crypto.NewSecureSessionFromKeys([32]byte{0x01}, [32]byte{0x02}))
+	session.Decrypter()
+
+	k.sendKeepAlive()
+
+	if is, want := len(unverified.Writes()), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := len(verified.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -1,18 +1,28 @@
 package hap
 
 import (
+	"github.com/brutella/hc/log"
 	"net"
+	"time"
 )
 
 // TCPListener listens for new connection and creates Connections for new connections
 type TCPListener struct {
 	*net.TCPListener
 	context Context
+
+	// KeepAlivePeriod is the period of TCP keep-alive probes for new connections.
+	// The default keep-alive settings of the operating system are used when the period is 0.
+	KeepAlivePeriod time.Duration
+
+	// WriteTimeout is the maximum duration of a write to a new connection.
+	// Writes don't time out when the timeout is 0.
+	WriteTimeout time.Duration
 }
 
 // NewTCPListener returns a new hap tcp listener.
 func NewTCPListener(l *net.TCPListener, context Context) *TCPListener {
-	return &TCPListener{l, context}
+	return &TCPListener{TCPListener: l, context: context}
 }
 
 // Accept creates and returns a Connection.
@@ -22,10 +32,17 @@ func (l *TCPListener) Accept() (c net.Conn, err error) {
 		return
 	}
 
-	// TODO(brutella) Check if we should use tcp keepalive
-	// conn.SetKeepAlive(true)
-	// conn.SetKeepAlivePeriod(3 * time.Minute)
+	if l.KeepAlivePeriod > 0 {
+		if err := conn.SetKeepAlive(true); err != nil {
+			log.Info.Println(err)
+		}
+		if err := conn.SetKeepAlivePeriod(l.KeepAlivePeriod); err != nil {
+			log.Info.Println(err)
+		}
+	}
+
 	hapConn := NewConnection(conn, l.context)
+	hapConn.writeTimeout = l.WriteTimeout
 
 	return hapConn, err
 }
@@ -0,0 +1,54 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package hap
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+// keepAlive returns true when TCP keep-alive is enabled for conn.
+func keepAlive(t *testing.T, conn *Connection) bool {
+	raw, err := conn.connection.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v int
+	raw.Control(func(fd uintptr) {
+		v, err = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v != 0
+}
+
+func TestListenerKeepsDefaultKeepAlive(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	listener := NewTCPListener(l, NewContextForSecuredDevice(nil))
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if is, want := keepAlive(t, conn.(*Connection)), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -3,16 +3,15 @@ package hap
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/brutella/hc/accessory"
 	"github.com/brutella/hc/characteristic"
 	"github.com/brutella/hc/hap/data"
-	"io/ioutil"
-	"net/http"
-	"strings"
+	"net"
 )
 
-// NewCharacteristicNotification returns an notification response for a characteristic from an accessory.
-func NewCharacteristicNotification(a *accessory.Accessory, c *characteristic.Characteristic) (*http.Response, error) {
+// NewCharacteristicNotification returns an notification message for a characteristic from an accessory.
+func NewCharacteristicNotification(a *accessory.Accessory, c *characteristic.Characteristic) ([]byte, error) {
 	body, err := Body(a, c)
 	if err != nil {
 		return nil, err
@@ -21,38 +20,48 @@ func NewCharacteristicNotification(a *accessory.Accessory, c *characteristic.Cha
 	return NewNotification(body), nil
 }
 
-// NewNotification returns a notification response with a specific body content.
-func NewNotification(body *bytes.Buffer) *http.Response {
-	resp := new(http.Response)
-	resp.Status = "200 OK"
-	resp.StatusCode = http.StatusOK
-	resp.ProtoMajor = 1
-	resp.ProtoMinor = 0
-	resp.Body = ioutil.NopCloser(body)
-	resp.ContentLength = int64(body.Len())
-	resp.Header = map[string][]string{}
-	resp.Header.Set("Content-Type", HTTPContentTypeHAPJson)
-
-	// Will be ignored unfortunately and won't be fixed https://github.com/golang/go/issues/9304
-	// Make sure to call FixProtocolSpecifier() instead
-	resp.Proto = "EVENT/1.0"
+// NewCharacteristicsNotification returns a notification message for multiple characteristics.
+func NewCharacteristicsNotification(chs []data.Characteristic) ([]byte, error) {
+	result, err := json.Marshal(data.Characteristics{Characteristics: chs})
+	if err != nil {
+		return nil, err
+	}
 
-	return resp
+	return NewNotification(bytes.NewBuffer(result)), nil
 }
 
-// FixProtocolSpecifier returns bytes where the http protocol specifier "HTTP/1.0" is replaced by "EVENT/1.0" in the argument bytes.
-// This fix is necessary because http.Response ignores the Proto field value.
+// NewNotification returns a notification message with a specific body content.
 //
-// Related to issue: https://github.com/golang/go/issues/9304
-func FixProtocolSpecifier(b []byte) []byte {
-	return []byte(strings.Replace(string(b), "HTTP/1.0", "EVENT/1.0", 1))
+// The message is encoded manually because http.Response ignores the protocol
+// specifier "EVENT/1.0" required by HAP (see https://github.com/golang/go/issues/9304).
+func NewNotification(body *bytes.Buffer) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "EVENT/1.0 200 OK\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", HTTPContentTypeHAPJson)
+	fmt.Fprintf(&b, "Content-Length: %d\r\n", body.Len())
+	fmt.Fprintf(&b, "\r\n")
+	b.Write(body.Bytes())
+
+	return b.Bytes()
+}
+
+// writeNotification writes a notification message to a connection.
+// If the connection is a hap connection, the message is not written
+// while a response is pending on the connection.
+func writeNotification(conn net.Conn, b []byte) error {
+	if c, ok := conn.(*Connection); ok == true {
+		return c.WriteEvent(b)
+	}
+
+	_, err := conn.Write(b)
+	return err
 }
 
 // Body returns the json body for an notification response as bytes.
 func Body(a *accessory.Accessory, c *characteristic.Characteristic) (*bytes.Buffer, error) {
 
 	ch := data.Characteristic{AccessoryID: a.GetID(), CharacteristicID: c.GetID(), Value: c.Value}
-	chars := data.Characteristics{[]data.Characteristic{ch}}
+	chars := data.Characteristics{Characteristics: []data.Characteristic{ch}}
 	result, err := json.Marshal(chars)
 	if err != nil {
 		return nil, err
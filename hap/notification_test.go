@@ -3,7 +3,6 @@ package hap
 import (
 	"github.com/brutella/hc/accessory"
 
-	"bytes"
 	"io/ioutil"
 	"strings"
 	"testing"
@@ -38,21 +37,12 @@ func TestCharacteristicNotification(t *testing.T) {
 
 func TestCharacteristicNotificationResponse(t *testing.T) {
 	a := accessory.New(info, accessory.TypeOther)
-	resp, err := NewCharacteristicNotification(a, a.Info.Name.Characteristic)
+	bytes, err := NewCharacteristicNotification(a, a.Info.Name.Characteristic)
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	var buffer = new(bytes.Buffer)
-	resp.Write(buffer)
-
-	bytes, err := ioutil.ReadAll(buffer)
-
-	if err != nil {
-		t.Fatal(err)
-	}
-	bytes = FixProtocolSpecifier(bytes)
 	if x := string(bytes); strings.HasPrefix(x, "EVENT/1.0 200 OK") == false {
 		t.Fatal(x)
 	}
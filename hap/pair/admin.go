@@ -1,6 +0,0 @@
-package pair
-
-const (
-	NonAdminPerm = 0x00
-	AdminPerm    = 0x01
-)
@@ -17,17 +17,20 @@ const (
 	// ErrCodeAuthenticationFailed is code for authentication error e.g. client proof is wrong
 	ErrCodeAuthenticationFailed errCode = 0x02
 
-	// ErrCodeTooManyAttempts is code for too many attempts error (not used)
+	// ErrCodeTooManyAttempts is code for too many attempts error; the client has to wait for the retry delay
 	ErrCodeTooManyAttempts errCode = 0x03
 
-	// ErrCodeUnknownPeer is code for unknown accessory or client error
-	ErrCodeUnknownPeer errCode = 0x04
-
 	// ErrCodeMaxPeer is code for reaching maximum number of peers error (not used)
-	ErrCodeMaxPeer errCode = 0x05
+	ErrCodeMaxPeer errCode = 0x04
+
+	// ErrCodeMaxAuthenticationAttempts is code for reaching maximum number of authentication attemps error
+	ErrCodeMaxAuthenticationAttempts errCode = 0x05
+
+	// ErrCodeUnavailable is code for unavailable error e.g. the accessory is already paired
+	ErrCodeUnavailable errCode = 0x06
 
-	// ErrCodeMaxAuthenticationAttempts is code for reaching maximum number of authentication attemps error (not used)
-	ErrCodeMaxAuthenticationAttempts errCode = 0x06
+	// ErrCodeBusy is code for busy error e.g. another pairing is in progress
+	ErrCodeBusy errCode = 0x07
 )
 
 func (t errCode) Byte() byte {
@@ -48,12 +51,14 @@ func (t errCode) String() string {
 		return "Authentication Failed"
 	case ErrCodeTooManyAttempts:
 		return "Too Many Attemps"
-	case ErrCodeUnknownPeer:
-		return "Unknown Peer"
 	case ErrCodeMaxPeer:
 		return "Max Peer"
 	case ErrCodeMaxAuthenticationAttempts:
 		return "Max Authentication Attempts"
+	case ErrCodeUnavailable:
+		return "Unavailable"
+	case ErrCodeBusy:
+		return "Busy"
 	}
 	return fmt.Sprintf("%v Unknown", byte(t))
 }
@@ -0,0 +1,36 @@
+package pair
+
+import (
+	"testing"
+)
+
+func TestErrCodes(t *testing.T) {
+	codes := []errCode{
+		ErrCodeNo,
+		ErrCodeUnknown,
+		ErrCodeAuthenticationFailed,
+		ErrCodeTooManyAttempts,
+		ErrCodeMaxPeer,
+		ErrCodeMaxAuthenticationAttempts,
+		ErrCodeUnavailable,
+		ErrCodeBusy,
+	}
+
+	// Every code has its own value and name
+	names := map[string]bool{}
+	for i, code := range codes {
+		if is, want := code.Byte(), byte(i); is != want {
+			t.Fatalf("is=%v want=%v", is, want)
+		}
+
+		names[code.String()] = true
+	}
+
+	if is, want := len(names), len(codes); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := ErrCodeUnavailable.String(), "Unavailable"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
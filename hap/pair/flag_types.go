@@ -0,0 +1,61 @@
+package pair
+
+import "fmt"
+
+// PairingFlags are sent in pair setup M1 to request a special kind of pairing.
+type PairingFlags uint32
+
+const (
+	// PairingFlagTransient requests a transient pair setup.
+	// Only session keys are derived from the setup code and no pairing is stored.
+	PairingFlagTransient PairingFlags = 0x00000010
+
+	// PairingFlagSplit requests a split pair setup.
+	// Together with PairingFlagTransient, the SRP verifier is saved to be used by
+	// a later pair setup with only PairingFlagSplit set. That pair setup doesn't
+	// require the setup code.
+	PairingFlagSplit PairingFlags = 0x01000000
+)
+
+// pairingFlags returns the flags of the little-endian encoded bytes b.
+func pairingFlags(b []byte) PairingFlags {
+	var f PairingFlags
+	for i := len(b) - 1; i >= 0; i-- {
+		f = f<<8 | PairingFlags(b[i])
+	}
+
+	return f
+}
+
+// Has returns true when all flags in flag are set.
+func (f PairingFlags) Has(flag PairingFlags) bool {
+	return f&flag == flag
+}
+
+// Bytes returns the little-endian encoded flags without trailing zero bytes.
+func (f PairingFlags) Bytes() []byte {
+	var b []byte
+	for {
+		b = append(b, byte(f))
+		f >>= 8
+		if f == 0 {
+			break
+		}
+	}
+
+	return b
+}
+
+func (f PairingFlags) String() string {
+	switch f {
+	case 0:
+		return "None"
+	case PairingFlagTransient:
+		return "Transient"
+	case PairingFlagSplit:
+		return "Split"
+	case PairingFlagTransient | PairingFlagSplit:
+		return "Transient Split"
+	}
+	return fmt.Sprintf("%#x Unknown", uint32(f))
+}
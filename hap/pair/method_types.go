@@ -17,6 +17,9 @@ const (
 
 	// PairingMethodDelete is used to delete a pairing with a client.
 	PairingMethodDelete PairMethodType = 0x04
+
+	// PairingMethodList is used to list all pairings.
+	PairingMethodList PairMethodType = 0x05
 )
 
 func (m PairMethodType) String() string {
@@ -29,6 +32,8 @@ func (m PairMethodType) String() string {
 		return "Add"
 	case PairingMethodDelete:
 		return "Delete"
+	case PairingMethodList:
+		return "List"
 	}
 	return fmt.Sprintf("%v Unknown", byte(m))
 }
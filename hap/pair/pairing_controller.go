@@ -1,6 +1,7 @@
 package pair
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/brutella/hc/db"
 	"github.com/brutella/hc/log"
@@ -22,36 +23,122 @@ func NewPairingController(database db.Database) *PairingController {
 	return &c
 }
 
-// Handle processes a container to pair with a new client without going through the pairing process.
-func (c *PairingController) Handle(cont util.Container) (util.Container, error) {
+// Handle processes a container to add, remove or list pairings without going through the pairing process.
+// The client with the name username, which verified its pairing in the current session,
+// must be an admin. Otherwise the request is refused with an authentication error.
+func (c *PairingController) Handle(cont util.Container, username string) (util.Container, error) {
 	method := PairMethodType(cont.GetByte(TagPairingMethod))
 	perm := cont.GetByte(TagPermission)
-	username := cont.GetString(TagUsername)
+	name := cont.GetString(TagUsername)
 	publicKey := cont.GetBytes(TagPublicKey)
 
 	log.Debug.Println("->     Method:", method)
 	log.Debug.Println("-> Permission:", perm)
-	log.Debug.Println("->   Username:", username)
+	log.Debug.Println("->   Username:", name)
 	log.Debug.Println("->       LTPK:", publicKey)
 
-	entity := db.NewEntity(username, publicKey, nil)
-
 	out := util.NewTLV8Container()
 	out.SetByte(TagSequence, 0x2)
 
+	switch method {
+	case PairingMethodDelete, PairingMethodAdd, PairingMethodList:
+		if c.isAdmin(username) == false {
+			log.Info.Printf("Client '%s' is not allowed to change pairings\n", username)
+			out.SetByte(TagErrCode, ErrCodeAuthenticationFailed.Byte())
+			return out, nil
+		}
+	default:
+		return nil, fmt.Errorf("Invalid pairing method type %v", method)
+	}
+
 	switch method {
 	case PairingMethodDelete:
-		log.Debug.Printf("Remove LTPK for client '%s'\n", username)
-		c.database.DeleteEntity(entity)
+		log.Debug.Printf("Remove LTPK for client '%s'\n", name)
+		if err := RemovePairing(c.database, name); err != nil {
+			return nil, err
+		}
 	case PairingMethodAdd:
-		err := c.database.SaveEntity(entity)
+		// An existing pairing can only be updated with the same public key
+		if pairing, err := c.database.PairingWithName(name); err == nil {
+			if bytes.Equal(pairing.PublicKey, publicKey) == false {
+				log.Info.Printf("Client '%s' is already paired with a different public key\n", name)
+				out.SetByte(TagErrCode, ErrCodeUnknown.Byte())
+				return out, nil
+			}
+
+			err := c.database.UpdatePairing(name, func(p *db.Pairing) {
+				p.Permission = perm
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return out, nil
+		}
+
+		err := c.database.SavePairing(db.NewPairing(name, publicKey, perm))
 		if err != nil {
 			log.Info.Panic(err)
 			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("Invalid pairing method type %v", method)
+	case PairingMethodList:
+		return c.listPairings(out)
+	}
+
+	return out, nil
+}
+
+// listPairings adds the name, public key and permission of every paired client to out.
+// The pairings are separated by a separator item.
+func (c *PairingController) listPairings(out util.Container) (util.Container, error) {
+	pairings, err := c.database.Pairings()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range pairings {
+		if i > 0 {
+			out.SetSeparator(TagSeparator)
+		}
+
+		out.SetString(TagUsername, p.Name)
+		out.SetBytes(TagPublicKey, p.PublicKey)
+		out.SetByte(TagPermission, p.Permission)
 	}
 
 	return out, nil
 }
+
+// isAdmin returns true when the client with the name username is paired as admin.
+func (c *PairingController) isAdmin(username string) bool {
+	pairing, err := c.database.PairingWithName(username)
+	if err != nil {
+		return false
+	}
+
+	return pairing.IsAdmin()
+}
+
+// RemovePairing removes the pairing of the client with the specified name.
+// When no admin is paired anymore, all pairings are removed.
+func RemovePairing(database db.Database, name string) error {
+	database.DeletePairing(name)
+
+	pairings, err := database.Pairings()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pairings {
+		if p.IsAdmin() == true {
+			return nil
+		}
+	}
+
+	for _, p := range pairings {
+		log.Debug.Printf("Remove LTPK for client '%s' because no admin is paired\n", p.Name)
+		database.DeletePairing(p.Name)
+	}
+
+	return nil
+}
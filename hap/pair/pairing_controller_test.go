@@ -11,12 +11,12 @@ import (
 func TestUnknownPairingMethod(t *testing.T) {
 	tlv8 := util.NewTLV8Container()
 	tlv8.SetByte(TagPairingMethod, 0x09)
-	tlv8.SetByte(TagPermission, AdminPerm)
+	tlv8.SetByte(TagPermission, db.PermissionAdmin)
 
 	database, _ := db.NewDatabase(os.TempDir())
 	controller := NewPairingController(database)
 
-	out, err := controller.Handle(tlv8)
+	out, err := controller.Handle(tlv8, "")
 
 	if err == nil {
 		t.Fatal("expected error for unknown pairing method")
@@ -30,14 +30,15 @@ func TestAddPairing(t *testing.T) {
 	in := util.NewTLV8Container()
 	in.SetByte(TagPairingMethod, PairingMethodAdd.Byte())
 	in.SetByte(TagSequence, 0x01)
-	in.SetByte(TagPermission, AdminPerm)
+	in.SetByte(TagPermission, db.PermissionAdmin)
 	in.SetString(TagUsername, "Unit Test")
 	in.SetBytes(TagPublicKey, []byte{0x01, 0x02})
 
-	database, _ := db.NewDatabase(os.TempDir())
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(newAdmin("Admin"))
 	controller := NewPairingController(database)
 
-	out, err := controller.Handle(in)
+	out, err := controller.Handle(in, "Admin")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -47,13 +48,79 @@ func TestAddPairing(t *testing.T) {
 	if is, want := out.GetByte(TagSequence), byte(0x2); is != want {
 		t.Fatalf("is=%v want=%v", is, want)
 	}
+	if is, want := out.GetByte(TagErrCode), ErrCodeNo.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	pairing, err := database.PairingWithName("Unit Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is, want := pairing.Permission, db.PermissionAdmin; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestAddPairingUpdatesPermission(t *testing.T) {
+	in := util.NewTLV8Container()
+	in.SetByte(TagPairingMethod, PairingMethodAdd.Byte())
+	in.SetByte(TagSequence, 0x01)
+	in.SetByte(TagPermission, db.PermissionAdmin)
+	in.SetString(TagUsername, "User")
+	in.SetBytes(TagPublicKey, []byte{0x03})
+
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(newAdmin("Admin"))
+	user := db.NewPairing("User", []byte{0x03}, db.PermissionUser)
+	user.Nickname = "iPad"
+	database.SavePairing(user)
+	controller := NewPairingController(database)
+
+	if _, err := controller.Handle(in, "Admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	pairing, err := database.PairingWithName("User")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is, want := pairing.IsAdmin(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := pairing.Nickname, "iPad"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestAddPairingNonAdmin(t *testing.T) {
+	in := util.NewTLV8Container()
+	in.SetByte(TagPairingMethod, PairingMethodAdd.Byte())
+	in.SetByte(TagSequence, 0x01)
+	in.SetByte(TagPermission, db.PermissionAdmin)
+	in.SetString(TagUsername, "Unit Test")
+	in.SetBytes(TagPublicKey, []byte{0x01, 0x02})
+
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(db.NewPairing("User", []byte{0x03}, db.PermissionUser))
+	controller := NewPairingController(database)
+
+	out, err := controller.Handle(in, "User")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is, want := out.GetByte(TagErrCode), ErrCodeAuthenticationFailed.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if _, err := database.PairingWithName("Unit Test"); err == nil {
+		t.Fatal("expected error")
+	}
 }
 
 func TestDeletePairing(t *testing.T) {
 	username := "Unit Test"
-	entity := db.NewEntity(username, []byte{0x01, 0x02}, nil)
-	database, _ := db.NewDatabase(os.TempDir())
-	database.SaveEntity(entity)
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(db.NewPairing(username, []byte{0x01, 0x02}, db.PermissionUser))
+	database.SavePairing(newAdmin("Admin"))
 
 	in := util.NewTLV8Container()
 	in.SetByte(TagPairingMethod, PairingMethodDelete.Byte())
@@ -62,7 +129,7 @@ func TestDeletePairing(t *testing.T) {
 
 	controller := NewPairingController(database)
 
-	out, err := controller.Handle(in)
+	out, err := controller.Handle(in, "Admin")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -74,7 +141,107 @@ func TestDeletePairing(t *testing.T) {
 		t.Fatalf("is=%v want=%v", is, want)
 	}
 
-	if _, err := database.EntityWithName(username); err == nil {
+	if _, err := database.PairingWithName(username); err == nil {
 		t.Fatal("expected error")
 	}
 }
+
+func TestDeletePairingNonAdmin(t *testing.T) {
+	username := "Unit Test"
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(db.NewPairing(username, []byte{0x01, 0x02}, db.PermissionUser))
+
+	in := util.NewTLV8Container()
+	in.SetByte(TagPairingMethod, PairingMethodDelete.Byte())
+	in.SetByte(TagSequence, 0x01)
+	in.SetString(TagUsername, username)
+
+	controller := NewPairingController(database)
+
+	out, err := controller.Handle(in, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is, want := out.GetByte(TagErrCode), ErrCodeAuthenticationFailed.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if _, err := database.PairingWithName(username); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newAdmin(name string) db.Pairing {
+	return db.NewPairing(name, []byte{0x05}, db.PermissionAdmin)
+}
+
+func TestListPairings(t *testing.T) {
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(newAdmin("Admin"))
+	database.SavePairing(db.NewPairing("User", []byte{0x03}, db.PermissionUser))
+	database.SaveEntity(db.NewEntity("Accessory", []byte{0x04}, []byte{0x05}))
+
+	in := util.NewTLV8Container()
+	in.SetByte(TagPairingMethod, PairingMethodList.Byte())
+	in.SetByte(TagSequence, 0x01)
+
+	controller := NewPairingController(database)
+	out, err := controller.Handle(in, "Admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeNo.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// sequence, 2 pairings with 3 items each, 1 separator
+	b := out.BytesBuffer().Bytes()
+	if is, want := len(b), 3+(2+5)+(2+1)+(2+1)+2+(2+4)+(2+1)+(2+1); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestListPairingsNonAdmin(t *testing.T) {
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(db.NewPairing("User", []byte{0x03}, db.PermissionUser))
+
+	in := util.NewTLV8Container()
+	in.SetByte(TagPairingMethod, PairingMethodList.Byte())
+	in.SetByte(TagSequence, 0x01)
+
+	controller := NewPairingController(database)
+	out, err := controller.Handle(in, "User")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeAuthenticationFailed.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestDeleteLastAdminRemovesAllPairings(t *testing.T) {
+	database, _ := db.NewTempDatabase()
+	database.SavePairing(newAdmin("Admin"))
+	database.SavePairing(db.NewPairing("User", []byte{0x03}, db.PermissionUser))
+	database.SaveEntity(db.NewEntity("Accessory", []byte{0x04}, []byte{0x05}))
+
+	in := util.NewTLV8Container()
+	in.SetByte(TagPairingMethod, PairingMethodDelete.Byte())
+	in.SetByte(TagSequence, 0x01)
+	in.SetString(TagUsername, "Admin")
+
+	controller := NewPairingController(database)
+	if _, err := controller.Handle(in, "Admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	pairings, _ := database.Pairings()
+	if is, want := len(pairings), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if _, err := database.EntityWithName("Accessory"); err != nil {
+		t.Fatal(err)
+	}
+}
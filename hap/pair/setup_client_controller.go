@@ -20,6 +20,7 @@ type SetupClientController struct {
 	client   hap.Device
 	session  *SetupClientSession
 	database db.Database
+	username string
 }
 
 // NewSetupClientController returns a new setup client controller.
@@ -55,7 +56,6 @@ func (setup *SetupClientController) Handle(in util.Container) (util.Container, e
 
 	code := errCode(in.GetByte(TagErrCode))
 	if code != ErrCodeNo {
-		log.Info.Panic(code)
 		return nil, code.Error()
 	}
 
@@ -97,8 +97,8 @@ func (setup *SetupClientController) handlePairStepStartResponse(in util.Containe
 		return nil, fmt.Errorf("B is invalid (%d bytes)", len(serverPublicKey))
 	}
 
-	fmt.Println("->     B:", hex.EncodeToString(serverPublicKey))
-	fmt.Println("->     s:", hex.EncodeToString(salt))
+	log.Debug.Println("->     B:", hex.EncodeToString(serverPublicKey))
+	log.Debug.Println("->     s:", hex.EncodeToString(salt))
 
 	// Client
 	// 1) Receive salt `s` and public key `B` and generates `S` and `A`
@@ -106,14 +106,14 @@ func (setup *SetupClientController) handlePairStepStartResponse(in util.Containe
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println("        S:", hex.EncodeToString(setup.session.PrivateKey))
+	log.Debug.Println("        S:", hex.EncodeToString(setup.session.PrivateKey))
 
 	// 2) Send public key `A` and proof `M1`
 	publicKey := setup.session.PublicKey // SRP public key
 	proof := setup.session.Proof         // M1
 
-	fmt.Println("<-     A:", hex.EncodeToString(publicKey))
-	fmt.Println("<-     M1:", hex.EncodeToString(proof))
+	log.Debug.Println("<-     A:", hex.EncodeToString(publicKey))
+	log.Debug.Println("<-     M1:", hex.EncodeToString(proof))
 
 	out := util.NewTLV8Container()
 	out.SetByte(TagPairingMethod, 0)
@@ -134,7 +134,7 @@ func (setup *SetupClientController) handlePairStepStartResponse(in util.Containe
 // - auth error
 func (setup *SetupClientController) handlePairStepVerifyResponse(in util.Container) (util.Container, error) {
 	serverProof := in.GetBytes(TagProof)
-	fmt.Println("->     M2:", hex.EncodeToString(serverProof))
+	log.Debug.Println("->     M2:", hex.EncodeToString(serverProof))
 
 	if setup.session.IsServerProofValid(serverProof) == false {
 		return nil, fmt.Errorf("M2 %s is invalid", hex.EncodeToString(serverProof))
@@ -145,7 +145,7 @@ func (setup *SetupClientController) handlePairStepVerifyResponse(in util.Contain
 		return nil, err
 	}
 
-	fmt.Println("        K:", hex.EncodeToString(setup.session.EncryptionKey[:]))
+	log.Debug.Println("        K:", hex.EncodeToString(setup.session.EncryptionKey[:]))
 
 	// 2) Send username, LTPK, signature as encrypted message
 	hash, err := hkdf.Sha512(setup.session.PrivateKey, []byte("Pair-Setup-Controller-Sign-Salt"), []byte("Pair-Setup-Controller-Sign-Info"))
@@ -174,7 +174,7 @@ func (setup *SetupClientController) handlePairStepVerifyResponse(in util.Contain
 	out.SetByte(TagSequence, PairStepKeyExchangeRequest.Byte())
 	out.SetBytes(TagEncryptedData, append(encryptedBytes, tag[:]...))
 
-	fmt.Println("<-   Encrypted:", hex.EncodeToString(out.GetBytes(TagEncryptedData)))
+	log.Debug.Println("<-   Encrypted:", hex.EncodeToString(out.GetBytes(TagEncryptedData)))
 
 	return out, nil
 }
@@ -194,33 +194,50 @@ func (setup *SetupClientController) handleKeyExchange(in util.Container) (util.C
 	message := data[:(len(data) - 16)]
 	var mac [16]byte
 	copy(mac[:], data[len(message):]) // 16 byte (MAC)
-	fmt.Println("->     Message:", hex.EncodeToString(message))
-	fmt.Println("->     MAC:", hex.EncodeToString(mac[:]))
+	log.Debug.Println("->     Message:", hex.EncodeToString(message))
+	log.Debug.Println("->     MAC:", hex.EncodeToString(mac[:]))
 
 	decrypted, err := chacha20poly1305.DecryptAndVerify(setup.session.EncryptionKey[:], []byte("PS-Msg06"), message, mac, nil)
+	if err != nil {
+		return nil, err
+	}
 
+	in, err = util.NewTLV8ContainerFromReader(bytes.NewBuffer(decrypted))
 	if err != nil {
-		fmt.Println(err)
-	} else {
-		decryptedBuf := bytes.NewBuffer(decrypted)
-		in, err := util.NewTLV8ContainerFromReader(decryptedBuf)
-		if err != nil {
-			fmt.Println(err)
-		}
-
-		username := in.GetString(TagUsername)
-		ltpk := in.GetBytes(TagPublicKey)
-		signature := in.GetBytes(TagSignature)
-		fmt.Println("->     Username:", username)
-		fmt.Println("->     LTPK:", hex.EncodeToString(ltpk))
-		fmt.Println("->     Signature:", hex.EncodeToString(signature))
-
-		entity := db.NewEntity(username, ltpk, nil)
-		err = setup.database.SaveEntity(entity)
-		if err != nil {
-			log.Info.Panic(err)
-		}
+		return nil, err
+	}
+
+	username := in.GetString(TagUsername)
+	ltpk := in.GetBytes(TagPublicKey)
+	signature := in.GetBytes(TagSignature)
+	log.Debug.Println("->     Username:", username)
+	log.Debug.Println("->     LTPK:", hex.EncodeToString(ltpk))
+	log.Debug.Println("->     Signature:", hex.EncodeToString(signature))
+
+	// Validate signature of hash `H2`, accessory name and LTPK
+	hash, err := hkdf.Sha512(setup.session.PrivateKey, []byte("Pair-Setup-Accessory-Sign-Salt"), []byte("Pair-Setup-Accessory-Sign-Info"))
+	if err != nil {
+		return nil, err
+	}
+
+	var material []byte
+	material = append(material, hash[:]...)
+	material = append(material, []byte(username)...)
+	material = append(material, ltpk...)
+
+	if crypto.ValidateED25519Signature(ltpk, material, signature) == false {
+		return nil, fmt.Errorf("Signature of accessory %s is invalid", username)
+	}
+
+	err = setup.database.SavePairing(db.NewPairing(username, ltpk, db.PermissionUser))
+	if err == nil {
+		setup.username = username
 	}
 
 	return nil, err
 }
+
+// Username returns the name of the accessory after pairing finished successfully.
+func (setup *SetupClientController) Username() string {
+	return setup.username
+}
@@ -1,10 +1,14 @@
 package pair
 
 import (
+	"github.com/brutella/hc/crypto/hkdf"
 	"github.com/brutella/hc/db"
 	"github.com/brutella/hc/hap"
 	"github.com/brutella/hc/util"
+
+	"bytes"
 	"testing"
+	"time"
 )
 
 // Tests the pairing setup
@@ -22,7 +26,7 @@ func TestPairingIntegration(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	controller, err := NewSetupServerController(bridge, database)
+	controller, err := NewSetupServerController(bridge, database, NewSetupLimiter(storage), NewVerifierStore(storage))
 
 	if err != nil {
 		t.Fatal(err)
@@ -78,3 +82,229 @@ func TestPairingIntegration(t *testing.T) {
 		t.Fatal(request)
 	}
 }
+
+func TestPairSetupWhenPaired(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	database := db.NewDatabaseWithStorage(storage)
+	bridge, _ := hap.NewSecuredDevice("Macbook Bridge", "001-02-003", database)
+	database.SavePairing(db.NewPairing("Client", []byte{0x01}, db.PermissionAdmin))
+
+	limiter := NewSetupLimiter(storage)
+	controller, err := NewSetupServerController(bridge, database, limiter, NewVerifierStore(storage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := util.NewTLV8Container()
+	in.SetByte(TagSequence, PairStepStartRequest.Byte())
+
+	out, err := controller.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeUnavailable.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	limiter.OpenPairing(time.Minute)
+
+	out, err = controller.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeNo.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+// setupTransient runs pair setup M1 to M4 with flags and returns the client session.
+func setupTransient(t *testing.T, controller *SetupServerController, pin string, flags PairingFlags) *SetupClientSession {
+	in := util.NewTLV8Container()
+	in.SetByte(TagSequence, PairStepStartRequest.Byte())
+	in.SetBytes(TagFlags, flags.Bytes())
+
+	out, err := controller.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeNo.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := pairingFlags(out.GetBytes(TagFlags)), flags; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	client := NewSetupClientSession("Pair-Setup", pin)
+	if err := client.GenerateKeys(out.GetBytes(TagSalt), out.GetBytes(TagPublicKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	in = util.NewTLV8Container()
+	in.SetByte(TagSequence, PairStepVerifyRequest.Byte())
+	in.SetBytes(TagPublicKey, client.PublicKey)
+	in.SetBytes(TagProof, client.Proof)
+
+	out, err = controller.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeNo.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if client.IsServerProofValid(out.GetBytes(TagProof)) == false {
+		t.Fatal("invalid server proof")
+	}
+
+	return client
+}
+
+func TestTransientPairSetup(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	database := db.NewDatabaseWithStorage(storage)
+	bridge, _ := hap.NewSecuredDevice("Macbook Bridge", "001-02-003", database)
+
+	controller, err := NewSetupServerController(bridge, database, NewSetupLimiter(storage), NewVerifierStore(storage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := setupTransient(t, controller, "001-02-003", PairingFlagTransient)
+
+	if is, want := controller.Transient(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	read, _ := hkdf.Sha512(client.PrivateKey, []byte("SplitSetupSalt"), []byte("AccessoryEncrypt-Control"))
+	write, _ := hkdf.Sha512(client.PrivateKey, []byte("SplitSetupSalt"), []byte("ControllerEncrypt-Accessory"))
+	encryptKey, decryptKey := controller.SessionKeys()
+	if encryptKey != read || decryptKey != write {
+		t.Fatal("session keys don't match")
+	}
+
+	if is, want := IsPaired(database), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := controller.step, PairStepWaiting; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestSplitPairSetup(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	database := db.NewDatabaseWithStorage(storage)
+	bridge, _ := hap.NewSecuredDevice("Macbook Bridge", "001-02-003", database)
+	verifier := NewVerifierStore(storage)
+
+	controller, err := NewSetupServerController(bridge, database, NewSetupLimiter(storage), verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Split pair setup requires a saved verifier
+	if _, _, err := verifier.Verifier(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	in := util.NewTLV8Container()
+	in.SetByte(TagSequence, PairStepStartRequest.Byte())
+	in.SetBytes(TagFlags, PairingFlagSplit.Bytes())
+	out, err := controller.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(TagErrCode), ErrCodeAuthenticationFailed.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	setupTransient(t, controller, "001-02-003", PairingFlagTransient|PairingFlagSplit)
+
+	// The verifier is used after the pin changed
+	bridge.SetPin("111-22-333")
+	setupTransient(t, controller, "001-02-003", PairingFlagSplit)
+
+	if is, want := controller.Transient(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestPairingFlags(t *testing.T) {
+	f := PairingFlagTransient | PairingFlagSplit
+	if is, want := f.Bytes(), []byte{0x10, 0x00, 0x00, 0x01}; bytes.Equal(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := pairingFlags(f.Bytes()), f; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := pairingFlags(nil), PairingFlags(0); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+// countItems returns the number of items with tag in the tlv8 encoded bytes b.
+func countItems(b []byte, tag uint8) int {
+	n := 0
+	for len(b) >= 2 {
+		if b[0] == tag {
+			n++
+		}
+		b = b[2+int(b[1]):]
+	}
+
+	return n
+}
+
+func TestKeyExchangeResponseState(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	database := db.NewDatabaseWithStorage(storage)
+	bridge, _ := hap.NewSecuredDevice("Macbook Bridge", "001-02-003", database)
+
+	controller, err := NewSetupServerController(bridge, database, NewSetupLimiter(storage), NewVerifierStore(storage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientDatabase, _ := db.NewTempDatabase()
+	client, _ := hap.NewDevice("Client", clientDatabase)
+	clientController := NewSetupClientController("001-02-003", client, clientDatabase)
+
+	// M1 to M5
+	r := clientController.InitialPairingRequest()
+	for i := 0; i < 2; i++ {
+		if r, err = HandleReaderForHandler(r, controller); err != nil {
+			t.Fatal(err)
+		}
+
+		if r, err = HandleReaderForHandler(r, clientController); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in, err := util.NewTLV8ContainerFromReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// M6
+	out, err := controller.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := countItems(out.BytesBuffer().Bytes(), TagSequence), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := out.GetByte(TagSequence), PairStepKeyExchangeResponse.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,206 @@
+package pair
+
+import (
+	"github.com/brutella/hc/log"
+	"github.com/brutella/hc/util"
+
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxSetupAttempts is the maximum number of failed pair setup attempts.
+// Once reached, pair setup is not possible anymore.
+const MaxSetupAttempts = 100
+
+// setupTimeout is the time after which a running pair setup may be
+// replaced by a pair setup on a different connection.
+const setupTimeout = time.Minute
+
+// maxSetupBackoff is the maximum time a client has to wait after a failed attempt.
+const maxSetupBackoff = time.Hour
+
+// SetupLimiter protects pair setup against brute-force attacks on the setup code.
+//
+// The number of failed attempts and the time of the last failed attempt are persisted in a storage.
+// After every failed attempt, the client has to wait exponentially longer before the next attempt.
+// After MaxSetupAttempts failed attempts, pair setup is refused until the attempts are reset.
+// Only one pair setup may run at a time.
+//
+// Once the accessory is paired, pair setup is only possible after pairing was re-opened.
+type SetupLimiter struct {
+	storage util.Storage
+
+	attempts    int         // failed attempts
+	lastFailure time.Time   // time of the last failed attempt
+	owner       interface{} // the running pair setup
+	expires     time.Time   // time when the running pair setup expires
+	openUntil   time.Time   // pairing is open until this time
+
+	mutex *sync.Mutex
+}
+
+// NewSetupLimiter returns a limiter which persists the failed attempts and
+// the time of the last failed attempt in storage.
+func NewSetupLimiter(storage util.Storage) *SetupLimiter {
+	l := SetupLimiter{
+		storage: storage,
+		mutex:   &sync.Mutex{},
+	}
+
+	if b, err := storage.Get("setupAttempts"); err == nil && len(b) > 0 {
+		if n, err := strconv.Atoi(string(b)); err == nil {
+			l.attempts = n
+		}
+	}
+
+	if b, err := storage.Get("setupLastFailure"); err == nil && len(b) > 0 {
+		if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			l.lastFailure = time.Unix(0, n)
+		}
+	}
+
+	return &l
+}
+
+// Begin starts a pair setup for owner.
+//
+// It returns ErrCodeMaxAuthenticationAttempts when the maximum number of attempts is reached,
+// ErrCodeTooManyAttempts with the time to wait when the client has to back off, and
+// ErrCodeBusy when another pair setup is running. Otherwise it returns ErrCodeNo.
+func (l *SetupLimiter) Begin(owner interface{}) (errCode, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+
+	if l.attempts >= MaxSetupAttempts {
+		return ErrCodeMaxAuthenticationAttempts, 0
+	}
+
+	if wait := l.lastFailure.Add(l.backoff()).Sub(now); wait > 0 {
+		return ErrCodeTooManyAttempts, wait
+	}
+
+	if l.owner != nil && l.owner != owner && now.Before(l.expires) {
+		return ErrCodeBusy, 0
+	}
+
+	l.owner = owner
+	l.expires = now.Add(setupTimeout)
+
+	return ErrCodeNo, 0
+}
+
+// End ends the pair setup of owner.
+func (l *SetupLimiter) End(owner interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.owner == owner {
+		l.owner = nil
+	}
+}
+
+// Failed records a failed attempt.
+func (l *SetupLimiter) Failed() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.attempts++
+	l.lastFailure = time.Now()
+	log.Info.Printf("Pair setup failed (%d of %d attempts)\n", l.attempts, MaxSetupAttempts)
+	l.save()
+}
+
+// Reset resets the failed attempts.
+func (l *SetupLimiter) Reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.attempts = 0
+	l.lastFailure = time.Time{}
+	l.save()
+}
+
+// OpenPairing allows pair setup while the accessory is already paired,
+// until timeout is reached or a client paired successfully.
+func (l *SetupLimiter) OpenPairing(timeout time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.openUntil = time.Now().Add(timeout)
+}
+
+// ClosePairing disallows pair setup while the accessory is paired.
+func (l *SetupLimiter) ClosePairing() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.openUntil = time.Time{}
+}
+
+// PairingOpen returns true when pairing was re-opened.
+func (l *SetupLimiter) PairingOpen() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return time.Now().Before(l.openUntil)
+}
+
+// Attempts returns the number of failed attempts.
+func (l *SetupLimiter) Attempts() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.attempts
+}
+
+// backoff returns the time to wait after the last failed attempt.
+// The first failed attempt doesn't require to wait.
+func (l *SetupLimiter) backoff() time.Duration {
+	if l.attempts < 2 {
+		return 0
+	}
+
+	// Avoid overflow for large number of attempts
+	if l.attempts > 20 {
+		return maxSetupBackoff
+	}
+
+	d := time.Second << uint(l.attempts-2)
+	if d > maxSetupBackoff {
+		return maxSetupBackoff
+	}
+
+	return d
+}
+
+// retryDelayBytes returns the bytes of the retry delay value in seconds.
+func retryDelayBytes(d time.Duration) []byte {
+	secs := uint64((d + time.Second - 1) / time.Second)
+
+	var b []byte
+	for {
+		b = append(b, byte(secs))
+		secs >>= 8
+		if secs == 0 {
+			break
+		}
+	}
+
+	return b
+}
+
+func (l *SetupLimiter) save() {
+	if err := l.storage.Set("setupAttempts", []byte(fmt.Sprintf("%d", l.attempts))); err != nil {
+		log.Info.Println(err)
+	}
+
+	if l.lastFailure.IsZero() {
+		l.storage.Delete("setupLastFailure")
+	} else if err := l.storage.Set("setupLastFailure", []byte(fmt.Sprintf("%d", l.lastFailure.UnixNano()))); err != nil {
+		log.Info.Println(err)
+	}
+}
@@ -0,0 +1,102 @@
+package pair
+
+import (
+	"github.com/brutella/hc/util"
+
+	"testing"
+	"time"
+)
+
+func TestSetupLimiterBusy(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	l := NewSetupLimiter(storage)
+
+	if code, _ := l.Begin("a"); code != ErrCodeNo {
+		t.Fatal(code)
+	}
+
+	if code, _ := l.Begin("b"); code != ErrCodeBusy {
+		t.Fatal(code)
+	}
+
+	l.End("a")
+
+	if code, _ := l.Begin("b"); code != ErrCodeNo {
+		t.Fatal(code)
+	}
+}
+
+func TestSetupLimiterBackoff(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	l := NewSetupLimiter(storage)
+
+	l.Failed()
+	if code, _ := l.Begin("a"); code != ErrCodeNo {
+		t.Fatal(code)
+	}
+	l.End("a")
+
+	l.Failed()
+	code, wait := l.Begin("a")
+	if code != ErrCodeTooManyAttempts {
+		t.Fatal(code)
+	}
+
+	if is, want := wait > 0 && wait <= time.Second, true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestSetupLimiterMaxAttempts(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	l := NewSetupLimiter(storage)
+	for i := 0; i < MaxSetupAttempts; i++ {
+		l.Failed()
+	}
+
+	// Attempts are persisted
+	l = NewSetupLimiter(storage)
+	if is, want := l.Attempts(), MaxSetupAttempts; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if code, _ := l.Begin("a"); code != ErrCodeMaxAuthenticationAttempts {
+		t.Fatal(code)
+	}
+
+	// kTLVError_MaxTries
+	if is, want := ErrCodeMaxAuthenticationAttempts.Byte(), byte(0x05); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestRetryDelayBytes(t *testing.T) {
+	if is, want := retryDelayBytes(300*time.Second), []byte{0x2C, 0x01}; string(is) != string(want) {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestSetupLimiterReset(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	l := NewSetupLimiter(storage)
+	for i := 0; i < 10; i++ {
+		l.Failed()
+	}
+
+	// The back off is persisted
+	l = NewSetupLimiter(storage)
+	if code, _ := l.Begin("a"); code != ErrCodeTooManyAttempts {
+		t.Fatal(code)
+	}
+
+	l.Reset()
+
+	l = NewSetupLimiter(storage)
+	if is, want := l.Attempts(), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if code, _ := l.Begin("a"); code != ErrCodeNo {
+		t.Fatal(code)
+	}
+}
@@ -20,15 +20,26 @@ import (
 // is stored in the database.
 //
 // Pairing may fail because the pin is wrong or the key exchange failed (e.g. packet seals or SRP key authenticator is wrong, ...).
+// Failed attempts are counted by a limiter, which also makes sure that only one pairing runs at a time.
+//
+// A transient pair setup ends after the SRP proofs were exchanged. Nothing is stored and the
+// session keys are derived from the SRP shared secret. A split pair setup uses the SRP verifier of a
+// previous transient split pair setup instead of the pin, which is kept in a verifier store.
 type SetupServerController struct {
 	device   hap.SecuredDevice
 	session  *SetupServerSession
 	step     PairStepType
 	database db.Database
+	limiter  *SetupLimiter
+	verifier *VerifierStore
+	flags    PairingFlags
+
+	encryptKey [32]byte // accessory to controller
+	decryptKey [32]byte // controller to accessory
 }
 
 // NewSetupServerController returns a new pair setup controller.
-func NewSetupServerController(device hap.SecuredDevice, database db.Database) (*SetupServerController, error) {
+func NewSetupServerController(device hap.SecuredDevice, database db.Database, limiter *SetupLimiter, verifier *VerifierStore) (*SetupServerController, error) {
 	if len(device.PrivateKey()) == 0 {
 		return nil, errors.New("no private key for pairing available")
 	}
@@ -42,6 +53,8 @@ func NewSetupServerController(device hap.SecuredDevice, database db.Database) (*
 		device:   device,
 		session:  session,
 		database: database,
+		limiter:  limiter,
+		verifier: verifier,
 		step:     PairStepWaiting,
 	}
 
@@ -100,8 +113,54 @@ func (setup *SetupServerController) handlePairStart(in util.Container) (util.Con
 	setup.step = PairStepStartResponse
 
 	out.SetByte(TagSequence, setup.step.Byte())
+
+	if IsPaired(setup.database) == true && setup.limiter.PairingOpen() == false {
+		log.Info.Println("Pair setup refused: already paired")
+		setup.step = PairStepWaiting
+		out.SetByte(TagErrCode, ErrCodeUnavailable.Byte())
+		return out, nil
+	}
+
+	if code, wait := setup.limiter.Begin(setup); code != ErrCodeNo {
+		log.Info.Println("Pair setup refused:", code)
+		setup.step = PairStepWaiting
+		out.SetByte(TagErrCode, code.Byte())
+		if wait > 0 {
+			out.SetBytes(TagRetryDelay, retryDelayBytes(wait))
+		}
+		return out, nil
+	}
+
+	setup.flags = pairingFlags(in.GetBytes(TagFlags))
+	log.Debug.Println("->     Flags:", setup.flags)
+
+	var session *SetupServerSession
+	var err error
+	if setup.flags.Has(PairingFlagSplit) == true && setup.flags.Has(PairingFlagTransient) == false {
+		// Split pair setup uses the verifier of a previous transient split pair setup
+		var salt, verifier []byte
+		if salt, verifier, err = setup.verifier.Verifier(); err != nil {
+			log.Info.Println("Split pair setup refused:", err)
+			setup.reset()
+			out.SetByte(TagErrCode, ErrCodeAuthenticationFailed.Byte())
+			return out, nil
+		}
+		session, err = NewSetupServerSessionWithVerifier(setup.device.Name(), salt, verifier)
+	} else {
+		// Every pairing attempt uses a new session with the current pin
+		session, err = NewSetupServerSession(setup.device.Name(), setup.device.Pin())
+	}
+
+	if err != nil {
+		setup.reset()
+		return nil, err
+	}
+	setup.session = session
 	out.SetBytes(TagPublicKey, setup.session.PublicKey)
 	out.SetBytes(TagSalt, setup.session.Salt)
+	if setup.flags != 0 {
+		out.SetBytes(TagFlags, setup.flags.Bytes())
+	}
 
 	log.Debug.Println("<-     B:", hex.EncodeToString(out.GetBytes(TagPublicKey)))
 	log.Debug.Println("<-     s:", hex.EncodeToString(out.GetBytes(TagSalt)))
@@ -136,10 +195,12 @@ func (setup *SetupServerController) handlePairVerify(in util.Container) (util.Co
 	proof, err := setup.session.ProofFromClientProof(clientProof)
 	if err != nil || len(proof) == 0 { // proof `M1` is wrong
 		log.Debug.Println("Proof M1 is wrong")
+		setup.limiter.Failed()
 		setup.reset()
 		out.SetByte(TagErrCode, ErrCodeAuthenticationFailed.Byte()) // return error 2
 	} else {
 		log.Debug.Println("Proof M1 is valid")
+		setup.limiter.Reset()
 		err := setup.session.SetupEncryptionKey([]byte("Pair-Setup-Encrypt-Salt"), []byte("Pair-Setup-Encrypt-Info"))
 		if err != nil {
 			return nil, err
@@ -147,6 +208,12 @@ func (setup *SetupServerController) handlePairVerify(in util.Container) (util.Co
 
 		// Return proof `M2`
 		out.SetBytes(TagProof, proof)
+
+		if setup.flags.Has(PairingFlagTransient) == true {
+			if err := setup.finishTransient(); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	log.Debug.Println("<-     M2:", hex.EncodeToString(out.GetBytes(TagProof)))
@@ -167,6 +234,8 @@ func (setup *SetupServerController) handlePairVerify(in util.Container) (util.Co
 // Server -> Client
 // - encrpyted tlv8: bridge ltpk, bridge name, signature (of hash, bridge name, ltpk)
 func (setup *SetupServerController) handleKeyExchange(in util.Container) (util.Container, error) {
+	defer setup.limiter.End(setup)
+
 	out := util.NewTLV8Container()
 
 	setup.step = PairStepKeyExchangeResponse
@@ -214,8 +283,13 @@ func (setup *SetupServerController) handleKeyExchange(in util.Container) (util.C
 		} else {
 			log.Debug.Println("ed25519 signature is valid")
 			// Store entity ltpk and name
-			entity := db.NewEntity(username, clientltpk, nil)
-			setup.database.SaveEntity(entity)
+			// The client which paired via pair setup is an admin
+			setup.database.SavePairing(db.NewPairing(username, clientltpk, db.PermissionAdmin))
+			setup.limiter.ClosePairing()
+			if setup.flags.Has(PairingFlagSplit) == true {
+				// The verifier of a split pair setup can only be used once
+				setup.verifier.Delete()
+			}
 			log.Debug.Printf("Stored ltpk '%s' for entity '%s'\n", hex.EncodeToString(clientltpk), username)
 
 			ltpk := setup.device.PublicKey()
@@ -244,7 +318,6 @@ func (setup *SetupServerController) handleKeyExchange(in util.Container) (util.C
 			log.Debug.Println("<-     Signature:", hex.EncodeToString(tlvPairKeyExchange.GetBytes(TagSignature)))
 
 			encrypted, mac, _ := chacha20poly1305.EncryptAndSeal(setup.session.EncryptionKey[:], []byte("PS-Msg06"), tlvPairKeyExchange.BytesBuffer().Bytes(), nil)
-			out.SetByte(TagSequence, PairStepKeyExchangeRequest.Byte())
 			out.SetBytes(TagEncryptedData, append(encrypted, mac[:]...))
 		}
 	}
@@ -252,7 +325,55 @@ func (setup *SetupServerController) handleKeyExchange(in util.Container) (util.C
 	return out, nil
 }
 
+// Transient returns true when the pair setup is transient.
+// A finished transient pair setup provides the session keys.
+func (setup *SetupServerController) Transient() bool {
+	return setup.flags.Has(PairingFlagTransient)
+}
+
+// SessionKeys returns the keys to encrypt outgoing and decrypt incoming data
+// after a transient pair setup.
+func (setup *SetupServerController) SessionKeys() (encryptKey [32]byte, decryptKey [32]byte) {
+	return setup.encryptKey, setup.decryptKey
+}
+
+// finishTransient derives the session keys from the SRP shared secret and ends the pair setup.
+// Nothing is stored, except the verifier of a split pair setup.
+func (setup *SetupServerController) finishTransient() error {
+	var err error
+	salt := []byte("SplitSetupSalt")
+	if setup.encryptKey, err = hkdf.Sha512(setup.session.PrivateKey, salt, []byte("AccessoryEncrypt-Control")); err != nil {
+		return err
+	}
+
+	if setup.decryptKey, err = hkdf.Sha512(setup.session.PrivateKey, salt, []byte("ControllerEncrypt-Accessory")); err != nil {
+		return err
+	}
+
+	if setup.flags.Has(PairingFlagSplit) == true {
+		if err := setup.verifier.Save(setup.session.Salt, setup.session.Verifier); err != nil {
+			log.Info.Println(err)
+		}
+	}
+
+	setup.reset()
+
+	return nil
+}
+
+// IsPaired returns true when a client is paired.
+func IsPaired(database db.Database) bool {
+	pairings, err := database.Pairings()
+	if err != nil {
+		log.Info.Println(err)
+		return true
+	}
+
+	return len(pairings) > 0
+}
+
 func (setup *SetupServerController) reset() {
 	setup.step = PairStepWaiting
+	setup.limiter.End(setup)
 	// TODO: reset session
 }
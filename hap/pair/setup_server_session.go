@@ -13,6 +13,7 @@ import (
 type SetupServerSession struct {
 	session       *srp.ServerSession
 	Salt          []byte   // s
+	Verifier      []byte   // v
 	PublicKey     []byte   // B
 	PrivateKey    []byte   // S
 	EncryptionKey [32]byte // K
@@ -29,20 +30,38 @@ func NewSetupServerSession(username, pin string) (*SetupServerSession, error) {
 		srp.SaltLength = 16
 		salt, v, err := srp.ComputeVerifier([]byte(pin))
 		if err == nil {
-			session := srp.NewServerSession([]byte(pairName), salt, v)
-			pairing := SetupServerSession{
-				session:   session,
-				Salt:      salt,
-				PublicKey: session.GetB(),
-				Username:  []byte(username),
-			}
-			return &pairing, nil
+			return newSetupServerSession(srp, username, salt, v), nil
 		}
 	}
 
 	return nil, err
 }
 
+// NewSetupServerSessionWithVerifier returns a new setup server session
+// which uses a previously computed salt and verifier instead of the pin.
+func NewSetupServerSessionWithVerifier(username string, salt, verifier []byte) (*SetupServerSession, error) {
+	pairName := []byte("Pair-Setup")
+	srp, err := srp.NewSRP(SRPGroup, sha512.New, KeyDerivativeFuncRFC2945(sha512.New, []byte(pairName)))
+	if err != nil {
+		return nil, err
+	}
+
+	return newSetupServerSession(srp, username, salt, verifier), nil
+}
+
+func newSetupServerSession(s *srp.SRP, username string, salt, verifier []byte) *SetupServerSession {
+	session := s.NewServerSession([]byte("Pair-Setup"), salt, verifier)
+	pairing := SetupServerSession{
+		session:   session,
+		Salt:      salt,
+		Verifier:  verifier,
+		PublicKey: session.GetB(),
+		Username:  []byte(username),
+	}
+
+	return &pairing
+}
+
 // ProofFromClientProof validates client proof (`M1`) and returns authenticator or error if proof is not valid.
 func (p *SetupServerSession) ProofFromClientProof(clientProof []byte) ([]byte, error) {
 	if !p.session.VerifyClientAuthenticator(clientProof) { // Validates M1 based on S and A
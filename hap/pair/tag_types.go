@@ -26,6 +26,9 @@ const (
 	// TagErrCode is the error tag. The value is of type ErrCode.
 	TagErrCode = 0x07
 
+	// TagRetryDelay is the retry delay tag. The value is the number of seconds to wait before the next attempt.
+	TagRetryDelay = 0x08
+
 	// TagMFiCertificate is the MFi certificate tag (currently not used).
 	TagMFiCertificate = 0x09
 
@@ -34,4 +37,10 @@ const (
 
 	// TagPermission is the permission tag. A value of 0x00 means a regular user, 0x01 is an admin which can remove and add pairings.
 	TagPermission = 0x0B
+
+	// TagFlags is the pairing flags tag. The value is of type PairingFlags.
+	TagFlags = 0x13
+
+	// TagSeparator is the separator tag. It has no value and separates items in a list.
+	TagSeparator = 0xFF
 )
@@ -0,0 +1,65 @@
+package pair
+
+import (
+	"github.com/brutella/hc/util"
+
+	"errors"
+	"sync"
+)
+
+// VerifierStore persists the SRP salt and verifier of a transient split pair setup,
+// which is used by the following split pair setup instead of the pin.
+type VerifierStore struct {
+	storage util.Storage
+	mutex   *sync.Mutex
+}
+
+// NewVerifierStore returns a store which persists the verifier in storage.
+func NewVerifierStore(storage util.Storage) *VerifierStore {
+	return &VerifierStore{
+		storage: storage,
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// Save saves the SRP salt and verifier of a transient split pair setup.
+func (s *VerifierStore) Save(salt, verifier []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.storage.Set("splitSalt", salt); err != nil {
+		return err
+	}
+
+	return s.storage.Set("splitVerifier", verifier)
+}
+
+// Verifier returns the saved SRP salt and verifier of a split pair setup.
+// It returns an error if no verifier was saved.
+func (s *VerifierStore) Verifier() (salt []byte, verifier []byte, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if salt, err = s.storage.Get("splitSalt"); err != nil {
+		return nil, nil, err
+	}
+
+	if verifier, err = s.storage.Get("splitVerifier"); err != nil {
+		return nil, nil, err
+	}
+
+	if len(salt) == 0 || len(verifier) == 0 {
+		return nil, nil, errors.New("No split pair setup verifier available")
+	}
+
+	return salt, verifier, nil
+}
+
+// Delete deletes the saved SRP salt and verifier.
+func (s *VerifierStore) Delete() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.storage.Delete("splitSalt")
+	s.storage.Delete("splitVerifier")
+}
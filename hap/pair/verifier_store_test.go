@@ -0,0 +1,39 @@
+package pair
+
+import (
+	"github.com/brutella/hc/util"
+
+	"bytes"
+	"testing"
+)
+
+func TestVerifierStore(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	s := NewVerifierStore(storage)
+
+	if _, _, err := s.Verifier(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if err := s.Save([]byte{0x01}, []byte{0x02, 0x03}); err != nil {
+		t.Fatal(err)
+	}
+
+	salt, verifier, err := NewVerifierStore(storage).Verifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := salt, []byte{0x01}; bytes.Equal(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := verifier, []byte{0x02, 0x03}; bytes.Equal(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	s.Delete()
+	if _, _, err := s.Verifier(); err == nil {
+		t.Fatal("expected error")
+	}
+}
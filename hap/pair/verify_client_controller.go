@@ -5,6 +5,7 @@ import (
 	"github.com/brutella/hc/crypto/chacha20poly1305"
 	"github.com/brutella/hc/db"
 	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/log"
 	"github.com/brutella/hc/util"
 
 	"bytes"
@@ -22,6 +23,7 @@ type VerifyClientController struct {
 	client   hap.Device
 	database db.Database
 	session  *VerifySession
+	username string
 }
 
 // NewVerifyClientController returns a new verify client controller.
@@ -61,6 +63,16 @@ func (verify *VerifyClientController) Handle(in util.Container) (util.Container,
 	return out, err
 }
 
+// SharedKey returns the shared key which was negotiated with the accessory.
+func (verify *VerifyClientController) SharedKey() [32]byte {
+	return verify.session.SharedKey
+}
+
+// Username returns the name of the accessory which was verified.
+func (verify *VerifyClientController) Username() string {
+	return verify.username
+}
+
 // InitialKeyVerifyRequest returns the first request the client sends to an accessory to start the paring verifcation process.
 // The request contains the client public key and sequence set to VerifyStepStartRequest.
 func (verify *VerifyClientController) InitialKeyVerifyRequest() io.Reader {
@@ -69,7 +81,7 @@ func (verify *VerifyClientController) InitialKeyVerifyRequest() io.Reader {
 	out.SetByte(TagSequence, VerifyStepStartRequest.Byte())
 	out.SetBytes(TagPublicKey, verify.session.PublicKey[:])
 
-	fmt.Println("<-     A:", hex.EncodeToString(out.GetBytes(TagPublicKey)))
+	log.Debug.Println("<-     A:", hex.EncodeToString(out.GetBytes(TagPublicKey)))
 
 	return out.BytesBuffer()
 }
@@ -95,11 +107,11 @@ func (verify *VerifyClientController) handlePairStepVerifyResponse(in util.Conta
 	verify.session.GenerateSharedKeyWithOtherPublicKey(otherPublicKey)
 	verify.session.SetupEncryptionKey([]byte("Pair-Verify-Encrypt-Salt"), []byte("Pair-Verify-Encrypt-Info"))
 
-	fmt.Println("Client")
-	fmt.Println("->   B:", hex.EncodeToString(serverPublicKey))
-	fmt.Println("     S:", hex.EncodeToString(verify.session.PrivateKey[:]))
-	fmt.Println("Shared:", hex.EncodeToString(verify.session.SharedKey[:]))
-	fmt.Println("     K:", hex.EncodeToString(verify.session.EncryptionKey[:]))
+	log.Debug.Println("Client")
+	log.Debug.Println("->   B:", hex.EncodeToString(serverPublicKey))
+	log.Debug.Println("     S:", hex.EncodeToString(verify.session.PrivateKey[:]))
+	log.Debug.Println("Shared:", hex.EncodeToString(verify.session.SharedKey[:]))
+	log.Debug.Println("     K:", hex.EncodeToString(verify.session.EncryptionKey[:]))
 
 	// Decrypt
 	data := in.GetBytes(TagEncryptedData)
@@ -120,8 +132,8 @@ func (verify *VerifyClientController) handlePairStepVerifyResponse(in util.Conta
 	username := decryptedIn.GetString(TagUsername)
 	signature := decryptedIn.GetBytes(TagSignature)
 
-	fmt.Println("    Username:", username)
-	fmt.Println("   Signature:", hex.EncodeToString(signature))
+	log.Debug.Println("    Username:", username)
+	log.Debug.Println("   Signature:", hex.EncodeToString(signature))
 
 	// Validate signature
 	var material []byte
@@ -129,18 +141,19 @@ func (verify *VerifyClientController) handlePairStepVerifyResponse(in util.Conta
 	material = append(material, username...)
 	material = append(material, verify.session.PublicKey[:]...)
 
-	var entity db.Entity
-	if entity, err = verify.database.EntityWithName(username); err != nil {
+	var pairing db.Pairing
+	if pairing, err = verify.database.PairingWithName(username); err != nil {
 		return nil, fmt.Errorf("Server %s is unknown", username)
 	}
 
-	if len(entity.PublicKey) == 0 {
+	if len(pairing.PublicKey) == 0 {
 		return nil, fmt.Errorf("No LTPK available for client %s", username)
 	}
 
-	if crypto.ValidateED25519Signature(entity.PublicKey, material, signature) == false {
+	if crypto.ValidateED25519Signature(pairing.PublicKey, material, signature) == false {
 		return nil, fmt.Errorf("Could not validate signature")
 	}
+	verify.username = username
 
 	out := util.NewTLV8Container()
 	out.SetByte(TagSequence, VerifyStepFinishRequest.Byte())
@@ -172,7 +185,7 @@ func (verify *VerifyClientController) handlePairStepVerifyResponse(in util.Conta
 func (verify *VerifyClientController) handlePairVerifyStepFinishResponse(in util.Container) (util.Container, error) {
 	code := errCode(in.GetByte(TagErrCode))
 	if code != ErrCodeNo {
-		fmt.Printf("Unexpected error %v\n", code)
+		return nil, code.Error()
 	}
 
 	return nil, nil
@@ -62,16 +62,14 @@ func TestPairVerifyIntegration(t *testing.T) {
 	controller := NewVerifyServerController(database, context)
 
 	clientDatabase, _ := db.NewTempDatabase()
-	bridgeEntity := db.NewEntity(bridge.Name(), bridge.PublicKey(), nil)
-	err = clientDatabase.SaveEntity(bridgeEntity)
+	err = clientDatabase.SavePairing(db.NewPairing(bridge.Name(), bridge.PublicKey(), db.PermissionUser))
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	client, _ := hap.NewDevice("HomeKit Client", clientDatabase)
-	clientEntity := db.NewEntity(client.Name(), client.PublicKey(), nil)
-	err = database.SaveEntity(clientEntity)
+	err = database.SavePairing(db.NewPairing(client.Name(), client.PublicKey(), db.PermissionAdmin))
 
 	if err != nil {
 		t.Fatal(err)
@@ -110,4 +108,8 @@ func TestPairVerifyIntegration(t *testing.T) {
 	if response != nil {
 		t.Fatal(response)
 	}
+
+	if p, _ := database.PairingWithName(client.Name()); p.LastVerified.IsZero() == true {
+		t.Fatal("last verified time not set")
+	}
 }
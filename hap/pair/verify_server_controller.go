@@ -10,6 +10,7 @@ import (
 	"github.com/brutella/hc/hap"
 	"github.com/brutella/hc/log"
 	"github.com/brutella/hc/util"
+	"time"
 )
 
 // VerifyServerController verifies the stored client public key and negotiates a shared secret
@@ -22,6 +23,7 @@ type VerifyServerController struct {
 	context  hap.Context
 	session  *VerifySession
 	step     VerifyStepType
+	username string
 }
 
 // NewVerifyServerController returns a new verify server controller.
@@ -41,6 +43,11 @@ func (verify *VerifyServerController) SharedKey() [32]byte {
 	return verify.session.SharedKey
 }
 
+// Username returns the name of the client, after the pairing was verified successfully.
+func (verify *VerifyServerController) Username() string {
+	return verify.username
+}
+
 // Handle processes a container to verify if a client is paired correctly.
 func (verify *VerifyServerController) Handle(in util.Container) (util.Container, error) {
 	var out util.Container
@@ -172,12 +179,12 @@ func (verify *VerifyServerController) handlePairVerifyFinish(in util.Container)
 		log.Debug.Println("    client:", username)
 		log.Debug.Println(" signature:", hex.EncodeToString(signature))
 
-		entity, err := verify.database.EntityWithName(username)
+		pairing, err := verify.database.PairingWithName(username)
 		if err != nil {
 			return nil, fmt.Errorf("Client %s is unknown", username)
 		}
 
-		if len(entity.PublicKey) == 0 {
+		if len(pairing.PublicKey) == 0 {
 			return nil, fmt.Errorf("No LTPK available for client %s", username)
 		}
 
@@ -186,12 +193,20 @@ func (verify *VerifyServerController) handlePairVerifyFinish(in util.Container)
 		material = append(material, []byte(username)...)
 		material = append(material, verify.session.PublicKey[:]...)
 
-		if crypto.ValidateED25519Signature(entity.PublicKey, material, signature) == false {
+		if crypto.ValidateED25519Signature(pairing.PublicKey, material, signature) == false {
 			log.Debug.Println("signature is invalid")
 			verify.reset()
-			out.SetByte(TagErrCode, ErrCodeUnknownPeer.Byte()) // return error 4
+			out.SetByte(TagErrCode, ErrCodeAuthenticationFailed.Byte()) // return error 2
 		} else {
 			log.Debug.Println("signature is valid")
+			verify.username = username
+
+			err := verify.database.UpdatePairing(username, func(p *db.Pairing) {
+				p.LastVerified = time.Now()
+			})
+			if err != nil {
+				log.Info.Println(err)
+			}
 		}
 	}
 
@@ -200,4 +215,5 @@ func (verify *VerifyServerController) handlePairVerifyFinish(in util.Container)
 
 func (verify *VerifyServerController) reset() {
 	verify.step = VerifyStepWaiting
+	verify.username = ""
 }
@@ -2,27 +2,79 @@ package hap
 
 import (
 	"github.com/brutella/hc/db"
+
+	"sync"
 )
 
 // SecuredDevice is a HomeKit device with a pin.
 type SecuredDevice interface {
 	Device
 	Pin() string
+
+	// SetPin changes the pin, which is required for pairings started afterwards.
+	SetPin(pin string)
+
+	// SetDevice replaces the name and keys of the device, e.g. after a factory reset.
+	SetDevice(d Device)
 }
 
 type securedDevice struct {
-	Device
-	pin string
+	device Device
+	pin    string
+	mutex  *sync.Mutex
 }
 
 // NewSecuredDevice returns a device for a specific name either loaded from the database or newly created.
 // Additionally other device can only pair with by providing the correct pin.
 func NewSecuredDevice(name string, pin string, database db.Database) (SecuredDevice, error) {
 	d, err := NewDevice(name, database)
-	return &securedDevice{d, pin}, err
+	return &securedDevice{d, pin, &sync.Mutex{}}, err
+}
+
+// Name returns the username used for pairing.
+func (d *securedDevice) Name() string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.device.Name()
+}
+
+// PrivateKey returns the private key used for pairing.
+func (d *securedDevice) PrivateKey() []byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.device.PrivateKey()
+}
+
+// PublicKey returns the public key used for pairing.
+func (d *securedDevice) PublicKey() []byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.device.PublicKey()
+}
+
+// SetDevice sets the name and keys of the device.
+func (d *securedDevice) SetDevice(device Device) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.device = device
 }
 
 // Pin returns the device pin.
 func (d *securedDevice) Pin() string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
 	return d.pin
 }
+
+// SetPin sets the device pin.
+func (d *securedDevice) SetPin(pin string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.pin = pin
+}
@@ -2,7 +2,11 @@ package hap
 
 import (
 	"github.com/brutella/hc/crypto"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/log"
 	"net"
+	"sync"
+	"time"
 )
 
 // Session contains objects (encrypter, decrypter, pairing handler,...) used to handle the data communication.
@@ -30,6 +34,39 @@ type Session interface {
 
 	// Connection returns the associated connection
 	Connection() net.Conn
+
+	// Username returns the name of the client which verified the pairing
+	// in this session, or an empty string
+	Username() string
+
+	// SetUsername sets the name of the client which verified the pairing
+	SetUsername(name string)
+
+	// SetEventsEnabled enables or disables events for the characteristic
+	// with accessory id aid and characteristic id iid
+	SetEventsEnabled(aid, iid int64, enable bool)
+
+	// EventsEnabled returns true when events are enabled for the characteristic
+	// with accessory id aid and characteristic id iid
+	EventsEnabled(aid, iid int64) bool
+
+	// DisableAccessoryEvents disables events for all characteristics
+	// of the accessory with id aid
+	DisableAccessoryEvents(aid int64)
+
+	// PrepareTimedWrite prepares a timed write with the process id pid,
+	// which expires after ttl
+	PrepareTimedWrite(pid uint64, ttl time.Duration)
+
+	// ExecuteTimedWrite returns true when a timed write with the process id pid
+	// was prepared and did not expire yet. The prepared timed write is reset.
+	ExecuteTimedWrite(pid uint64) bool
+}
+
+// characteristicKey identifies a characteristic by its accessory and characteristic id.
+type characteristicKey struct {
+	aid int64
+	iid int64
 }
 
 type session struct {
@@ -37,6 +74,15 @@ type session struct {
 	pairStartHandler  ContainerHandler
 	pairVerifyHandler PairVerifyHandler
 	connection        net.Conn
+	username          string
+
+	// Characteristics for which the client enabled events
+	events map[characteristicKey]bool
+	mutex  *sync.Mutex
+
+	// Process id and expiry date of the prepared timed write
+	timedWritePID     *uint64
+	timedWriteExpires time.Time
 
 	// Temporary variable to reference next cryptographer
 	nextCryptographer crypto.Cryptographer
@@ -46,6 +92,8 @@ type session struct {
 func NewSession(connection net.Conn) Session {
 	s := session{
 		connection: connection,
+		events:     map[characteristicKey]bool{},
+		mutex:      &sync.Mutex{},
 	}
 
 	return &s
@@ -56,6 +104,9 @@ func (s *session) Connection() net.Conn {
 }
 
 func (s *session) Decrypter() crypto.Decrypter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	// Return the next cryptographer when possible
 	// This allows sessions to switch encryption
 	if s.nextCryptographer != nil {
@@ -67,6 +118,9 @@ func (s *session) Decrypter() crypto.Decrypter {
 }
 
 func (s *session) Encrypter() crypto.Encrypter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	return s.cryptographer
 }
 
@@ -82,6 +136,9 @@ func (s *session) SetCryptographer(c crypto.Cryptographer) {
 	// Temporarily set the cryptographer as the nextCryptographer
 	// The nextCryptographer is used the next time Decrypter() is called.
 	// Otherwise the Encrypter() encrypts differently than the previous Decrypter()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	s.nextCryptographer = c
 }
 func (s *session) SetPairSetupHandler(c ContainerHandler) {
@@ -91,3 +148,92 @@ func (s *session) SetPairSetupHandler(c ContainerHandler) {
 func (s *session) SetPairVerifyHandler(c PairVerifyHandler) {
 	s.pairVerifyHandler = c
 }
+
+func (s *session) Username() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.username
+}
+
+func (s *session) SetUsername(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.username = name
+}
+
+func (s *session) SetEventsEnabled(aid, iid int64, enable bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := characteristicKey{aid, iid}
+	if enable == true {
+		s.events[key] = true
+	} else {
+		delete(s.events, key)
+	}
+}
+
+func (s *session) EventsEnabled(aid, iid int64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.events[characteristicKey{aid, iid}]
+}
+
+func (s *session) DisableAccessoryEvents(aid int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key := range s.events {
+		if key.aid == aid {
+			delete(s.events, key)
+		}
+	}
+}
+
+func (s *session) PrepareTimedWrite(pid uint64, ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.timedWritePID = &pid
+	s.timedWriteExpires = time.Now().Add(ttl)
+}
+
+func (s *session) ExecuteTimedWrite(pid uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prepared := s.timedWritePID
+	s.timedWritePID = nil
+
+	if prepared == nil || *prepared != pid {
+		return false
+	}
+
+	return time.Now().After(s.timedWriteExpires) == false
+}
+
+// CloseUnpairedSessions closes all connections which were verified by
+// a client which is not paired anymore.
+// The connections are closed after the current response was sent.
+func CloseUnpairedSessions(context Context, database db.Database) {
+	for _, conn := range context.ActiveConnections() {
+		session := context.GetSessionForConnection(conn)
+		if session == nil || len(session.Username()) == 0 {
+			continue
+		}
+
+		if _, err := database.PairingWithName(session.Username()); err == nil {
+			continue
+		}
+
+		log.Debug.Printf("Close connection of unpaired client '%s'\n", session.Username())
+		if c, ok := conn.(*Connection); ok == true {
+			c.CloseWhenInactive()
+		} else {
+			conn.Close()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package hap
+
+import (
+	"github.com/brutella/hc/crypto"
+
+	"sync"
+	"testing"
+)
+
+// Tests that the cryptographer can be changed while the session is used (run with -race)
+func TestSessionCryptographerConcurrently(t *testing.T) {
+	s := NewSession(nil)
+	c := crypto.NewSecureSessionFromKeys([32]byte{0x01}, [32]byte{0x02})
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.SetCryptographer(c)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.Decrypter()
+			s.Encrypter()
+		}
+	}()
+	wg.Wait()
+
+	// The cryptographer is switched by the next Decrypter() call
+	s.Decrypter()
+	if is, want := s.Encrypter(), c; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+// Tests that the username can be read while it is set (run with -race)
+func TestSessionUsernameConcurrently(t *testing.T) {
+	s := NewSession(nil)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.SetUsername("Client")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.Username()
+		}
+	}()
+	wg.Wait()
+
+	if is, want := s.Username(), "Client"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,76 @@
+// Package testserver runs HAP servers for tests of packages which talk to accessories.
+package testserver
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/event"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/http"
+	"github.com/brutella/hc/hap/pair"
+	"github.com/brutella/hc/util"
+
+	"context"
+	"sync"
+	"testing"
+)
+
+// Pin is the pin of the test servers.
+const Pin = "001-02-003"
+
+// Server is a running HAP server.
+type Server struct {
+	// Addr is the address of the server (host:port)
+	Addr string
+
+	// Context is the context of the server, which contains the active connections
+	Context hap.Context
+
+	cancel context.CancelFunc
+}
+
+// Start starts a HAP server with the device name and accessories on a free local port.
+// The first accessory acts as the bridge.
+func Start(t testing.TB, name string, as ...*accessory.Accessory) *Server {
+	storage, err := util.NewTempFileStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database := db.NewDatabaseWithStorage(storage)
+	device, err := hap.NewSecuredDevice(name, Pin, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	container := accessory.NewContainer()
+	for _, a := range as {
+		container.AddAccessory(a)
+	}
+
+	hapContext := hap.NewContextForSecuredDevice(device)
+	s := http.NewServer(http.Config{
+		Context:       hapContext,
+		Database:      database,
+		Container:     container,
+		Device:        device,
+		Mutex:         &sync.Mutex{},
+		Emitter:       event.NewEmitter(),
+		SetupLimiter:  pair.NewSetupLimiter(storage),
+		VerifierStore: pair.NewVerifierStore(storage),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.ListenAndServe(ctx)
+
+	return &Server{
+		Addr:    "127.0.0.1:" + s.Port(),
+		Context: hapContext,
+		cancel:  cancel,
+	}
+}
+
+// Stop stops the server.
+func (s *Server) Stop() {
+	s.cancel()
+}
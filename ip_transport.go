@@ -1,12 +1,11 @@
 package hc
 
 import (
-	"bytes"
 	"context"
-	"io/ioutil"
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/brutella/dnssd"
 	"github.com/brutella/hc/accessory"
@@ -16,6 +15,7 @@ import (
 	"github.com/brutella/hc/hap"
 	"github.com/brutella/hc/hap/endpoint"
 	"github.com/brutella/hc/hap/http"
+	"github.com/brutella/hc/hap/pair"
 	"github.com/brutella/hc/log"
 	"github.com/brutella/hc/util"
 	"github.com/gosexy/to"
@@ -37,6 +37,23 @@ type ipTransport struct {
 	device    hap.SecuredDevice
 	container *accessory.Container
 
+	// Accessories which were removed from the container
+	removed      map[*accessory.Accessory]bool
+	removedMutex *sync.Mutex
+
+	// Limits pair setup attempts and controls if pairing is open
+	setupLimiter *pair.SetupLimiter
+
+	// Stores the verifier of split pair setups
+	verifierStore *pair.VerifierStore
+
+	// Stores characteristic values when Config.PersistValues is true, otherwise nil
+	values *valueStore
+
+	// Event schedulers for active connections
+	schedulers     map[net.Conn]*hap.EventScheduler
+	schedulerMutex *sync.Mutex
+
 	// Used to communicate between different parts of the program (e.g. successful pairing with HomeKit)
 	emitter event.Emitter
 
@@ -64,7 +81,10 @@ type ipTransport struct {
 //
 // The transport is secured with an 8-digit pin, which must be entered
 // by an iOS client to successfully pair with the accessory. If the
-// provided transport config does not specify any pin, 00102003 is used.
+// provided transport config does not specify any pin, the pin stored in
+// the database is used. When there is no stored pin either, a random pin
+// is generated with NewRandomPin and logged. The pin is stored in the
+// database, so that it doesn't change when the transport is restarted.
 func NewIPTransport(config Config, a *accessory.Accessory, as ...*accessory.Accessory) (*ipTransport, error) {
 	// Find transport name which is visible in mDNS
 	name := a.Info.Name.GetValue()
@@ -82,12 +102,26 @@ func NewIPTransport(config Config, a *accessory.Accessory, as ...*accessory.Acce
 
 	database := db.NewDatabaseWithStorage(storage)
 
+	cfg.load(storage)
+
+	// Every installation gets its own pin
+	if len(cfg.Pin) == 0 {
+		cfg.Pin = NewRandomPin()
+		log.Info.Printf("Generated pin %s\n", cfg.Pin)
+	}
+
 	hap_pin, err := NewPin(cfg.Pin)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.load(storage)
+	if len(cfg.SetupId) == 0 {
+		cfg.SetupId = newSetupId()
+	}
+
+	if err := validateSetupId(cfg.SetupId); err != nil {
+		return nil, err
+	}
 
 	device, err := hap.NewSecuredDevice(cfg.id, hap_pin, database)
 	if err != nil {
@@ -109,11 +143,24 @@ func NewIPTransport(config Config, a *accessory.Accessory, as ...*accessory.Acce
 		container: accessory.NewContainer(),
 		mutex:     &sync.Mutex{},
 		context:   hap.NewContextForSecuredDevice(device),
-		emitter:   event.NewEmitter(),
-		responder: responder,
-		ctx:       ctx,
-		cancel:    cancel,
-		stopped:   make(chan struct{}),
+
+		removed:      map[*accessory.Accessory]bool{},
+		removedMutex: &sync.Mutex{},
+
+		setupLimiter:  pair.NewSetupLimiter(storage),
+		verifierStore: pair.NewVerifierStore(storage),
+
+		schedulers:     map[net.Conn]*hap.EventScheduler{},
+		schedulerMutex: &sync.Mutex{},
+		emitter:        event.NewEmitter(),
+		responder:      responder,
+		ctx:            ctx,
+		cancel:         cancel,
+		stopped:        make(chan struct{}),
+	}
+
+	if cfg.PersistValues == true {
+		t.values = newValueStore(storage, cfg.PersistDelay)
 	}
 
 	t.addAccessory(a)
@@ -122,13 +169,11 @@ func NewIPTransport(config Config, a *accessory.Accessory, as ...*accessory.Acce
 	}
 
 	// Users can only pair discoverable accessories
-	if t.isPaired() {
+	if t.isPaired() == true {
 		cfg.discoverable = false
 	}
 
-	cfg.categoryId = int(t.container.AccessoryType())
-	cfg.updateConfigHash(t.container.ContentHash())
-	cfg.save(storage)
+	t.updateConfig()
 
 	// Listen for events to update mDNS txt records
 	t.emitter.AddListener(t)
@@ -147,6 +192,12 @@ func (t *ipTransport) Start() {
 		Device:    t.device,
 		Mutex:     t.mutex,
 		Emitter:   t.emitter,
+
+		SetupLimiter:  t.setupLimiter,
+		VerifierStore: t.verifierStore,
+
+		TCPKeepAlivePeriod: t.config.TCPKeepAlivePeriod,
+		WriteTimeout:       t.config.WriteTimeout,
 	}
 
 	s := http.NewServer(config)
@@ -172,15 +223,17 @@ func (t *ipTransport) Start() {
 		mdnsStop <- struct{}{}
 	}()
 
-	// keepAliveCtx, keepAliveCancel := context.WithCancel(t.ctx)
-	// defer keepAliveCancel()
-	//
-	// // Send keep alive notifications to all connected clients every 10 minutes
-	// keepAlive := hap.NewKeepAlive(10*time.Minute, t.context)
-	// go func() {
-	// 	keepAlive.Start(keepAliveCtx)
-	// 	log.Info.Println("Keep alive stopped")
-	// }()
+	keepAliveCtx, keepAliveCancel := context.WithCancel(t.ctx)
+	defer keepAliveCancel()
+
+	// Send keep alive notifications to all connected clients
+	if interval := t.config.KeepAliveInterval; interval > 0 {
+		keepAlive := hap.NewKeepAlive(interval, t.context)
+		go func() {
+			keepAlive.Start(keepAliveCtx)
+			log.Debug.Println("Keep alive stopped")
+		}()
+	}
 
 	// Publish accessory ip
 	log.Info.Printf("Listening on port %s\n", s.Port())
@@ -204,24 +257,120 @@ func (t *ipTransport) Start() {
 func (t *ipTransport) Stop() <-chan struct{} {
 	t.cancel()
 
+	// Store pending characteristic values
+	if t.values != nil {
+		t.values.flush()
+	}
+
 	return t.stopped
 }
 
 // isPaired returns true when the transport is already paired
 func (t *ipTransport) isPaired() bool {
+	return pair.IsPaired(t.database)
+}
+
+func (t *ipTransport) updateMDNSReachability() {
+	t.config.discoverable = t.isPaired() == false || t.setupLimiter.PairingOpen() == true
+	t.updateMDNSText()
+}
+
+// Pin returns the pin of the transport e.g. "00102003".
+func (t *ipTransport) Pin() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 
-	// If more than one entity is stored in the database, we are paired with a device.
-	// The transport itself is a device and is stored in the database, therefore
-	// we have to check for more than one entity.
-	if es, err := t.database.Entities(); err == nil && len(es) > 1 {
-		return true
+	return t.config.Pin
+}
+
+// SetPin changes the pin of the running transport to a 8-numbers pin e.g. "00102003".
+// The new pin is stored and required for all pairings started afterwards.
+// The verifier of a previous split pair setup is deleted because it was derived from the old pin.
+// Existing pairings are not affected.
+func (t *ipTransport) SetPin(pin string) error {
+	hap_pin, err := NewPin(pin)
+	if err != nil {
+		return err
 	}
 
-	return false
+	t.mutex.Lock()
+	t.config.Pin = pin
+	t.device.SetPin(hap_pin)
+	t.verifierStore.Delete()
+	t.mutex.Unlock()
+
+	t.updateConfig()
+
+	return nil
 }
 
-func (t *ipTransport) updateMDNSReachability() {
-	t.config.discoverable = t.isPaired() == false
+// OpenPairing re-opens pairing mode while the transport is already paired.
+//
+// Until timeout is reached or a client paired successfully, the accessory is
+// discoverable and accepts pair setup with the pin.
+func (t *ipTransport) OpenPairing(timeout time.Duration) {
+	t.setupLimiter.OpenPairing(timeout)
+	t.updateMDNSReachability()
+
+	time.AfterFunc(timeout, t.updateMDNSReachability)
+}
+
+// AddAccessory adds an accessory to the running transport.
+//
+// The configuration number (c#) is incremented and the mDNS txt records are
+// republished, which makes iOS clients fetch the accessories again.
+func (t *ipTransport) AddAccessory(a *accessory.Accessory) {
+	t.mutex.Lock()
+	t.addAccessory(a)
+	t.mutex.Unlock()
+
+	t.updateConfig()
+	t.updateMDNSText()
+}
+
+// RemoveAccessory removes an accessory from the running transport.
+// The first accessory acts as the bridge and must not be removed.
+//
+// The configuration number (c#) is incremented and the mDNS txt records are
+// republished, which makes iOS clients fetch the accessories again.
+func (t *ipTransport) RemoveAccessory(a *accessory.Accessory) {
+	t.mutex.Lock()
+	if len(t.container.Accessories) > 0 && t.container.Accessories[0] == a {
+		t.mutex.Unlock()
+		log.Info.Println("The first accessory can't be removed")
+		return
+	}
+	t.container.RemoveAccessory(a)
+	t.mutex.Unlock()
+
+	t.removedMutex.Lock()
+	t.removed[a] = true
+	t.removedMutex.Unlock()
+
+	// Clients have to enable events again, when the accessory is added again
+	for _, conn := range t.context.ActiveConnections() {
+		if session := t.context.GetSessionForConnection(conn); session != nil {
+			session.DisableAccessoryEvents(a.GetID())
+		}
+	}
+
+	t.updateConfig()
+	t.updateMDNSText()
+}
+
+// updateConfig updates the category and config hash from the current accessories
+// and stores the config. The version is incremented when the config hash changed.
+func (t *ipTransport) updateConfig() {
+	t.mutex.Lock()
+	t.config.categoryId = int(t.container.AccessoryType())
+	t.config.updateConfigHash(t.container.ContentHash())
+	t.mutex.Unlock()
+
+	t.config.save(t.storage)
+}
+
+// updateMDNSText republishes the mDNS txt records when the service is announced.
+func (t *ipTransport) updateMDNSText() {
 	if t.handle != nil {
 		t.handle.UpdateText(t.config.txtRecords(), t.responder)
 	}
@@ -230,59 +379,115 @@ func (t *ipTransport) updateMDNSReachability() {
 func (t *ipTransport) addAccessory(a *accessory.Accessory) {
 	t.container.AddAccessory(a)
 
+	t.removedMutex.Lock()
+	_, readded := t.removed[a]
+	t.removedMutex.Unlock()
+
+	// Restore stored values before observing changes, which would store them again
+	if t.values != nil {
+		t.values.restore(a)
+	}
+
+	// The callbacks of a removed accessory are still registered
+	// and handle changes again when the accessory is re-added.
+	if readded == true {
+		t.removedMutex.Lock()
+		delete(t.removed, a)
+		t.removedMutex.Unlock()
+		return
+	}
+
 	for _, s := range a.Services {
 		for _, c := range s.Characteristics {
-			// When a characteristic value changes and events are enabled for this characteristic
-			// all listeners are notified. Since we don't track which client is interested in
-			// which characteristic change event, we send them to all active connections.
+			// When a characteristic value changes, the clients which enabled
+			// events for this characteristic are notified. The connection from
+			// which the value was changed is not notified.
 			onConnChange := func(conn net.Conn, c *characteristic.Characteristic, new, old interface{}) {
-				if c.Events == true {
-					t.notifyListener(a, c, conn)
-				}
+				t.valueChanged(a, c, new, conn)
 			}
 			c.OnValueUpdateFromConn(onConnChange)
 
 			onChange := func(c *characteristic.Characteristic, new, old interface{}) {
-				if c.Events == true {
-					t.notifyListener(a, c, nil)
-				}
+				t.valueChanged(a, c, new, nil)
 			}
 			c.OnValueUpdate(onChange)
 		}
 	}
 }
 
+// valueChanged notifies clients about the new value of a characteristic and stores the value.
+// Changes of removed accessories are ignored.
+func (t *ipTransport) valueChanged(a *accessory.Accessory, c *characteristic.Characteristic, new interface{}, except net.Conn) {
+	t.removedMutex.Lock()
+	removed := t.removed[a]
+	t.removedMutex.Unlock()
+
+	if removed == true {
+		return
+	}
+
+	t.notifyListener(a, c, except)
+
+	if t.values != nil {
+		t.values.store(a, c, new)
+	}
+}
+
+// notifyListener schedules an event notification for a characteristic for all active
+// connections, whose session enabled events for this characteristic.
+//
+// Events of programmable switches are sent immediately. Other events are coalesced and
+// rate limited per connection by an event scheduler.
 func (t *ipTransport) notifyListener(a *accessory.Accessory, c *characteristic.Characteristic, except net.Conn) {
 	conns := t.context.ActiveConnections()
+
+	t.schedulerMutex.Lock()
+	defer t.schedulerMutex.Unlock()
+
+	immediate := c.Type == characteristic.TypeProgrammableSwitchEvent
 	for _, conn := range conns {
 		if conn == except {
 			continue
 		}
-		resp, err := hap.NewCharacteristicNotification(a, c)
-		if err != nil {
-			log.Info.Panic(err)
+
+		session := t.context.GetSessionForConnection(conn)
+		if session == nil || session.EventsEnabled(a.GetID(), c.GetID()) == false {
+			continue
+		}
+
+		scheduler, ok := t.schedulers[conn]
+		if ok == false {
+			scheduler = hap.NewEventScheduler(conn, t.config.EventInterval)
+			t.schedulers[conn] = scheduler
 		}
 
-		// Write response into buffer to replace HTTP protocol
-		// specifier with EVENT as required by HAP
-		var buffer = new(bytes.Buffer)
-		resp.Write(buffer)
-		bytes, err := ioutil.ReadAll(buffer)
-		bytes = hap.FixProtocolSpecifier(bytes)
-		log.Debug.Printf("%s <- %s", conn.RemoteAddr(), string(bytes))
-		conn.Write(bytes)
+		scheduler.Schedule(a.GetID(), c.GetID(), c.Value, immediate)
+	}
+}
+
+// removeScheduler stops and removes the event scheduler of a closed connection.
+func (t *ipTransport) removeScheduler(conn net.Conn) {
+	t.schedulerMutex.Lock()
+	defer t.schedulerMutex.Unlock()
+
+	if scheduler, ok := t.schedulers[conn]; ok == true {
+		scheduler.Stop()
+		delete(t.schedulers, conn)
 	}
 }
 
-// Handles event which are sent when pairing with a device is added or removed
+// Handles event which are sent when pairing with a device is added or removed,
+// or when a connection is closed
 func (t *ipTransport) Handle(ev interface{}) {
-	switch ev.(type) {
+	switch e := ev.(type) {
 	case event.DevicePaired:
 		log.Debug.Printf("Event: paired with device")
 		t.updateMDNSReachability()
 	case event.DeviceUnpaired:
 		log.Debug.Printf("Event: unpaired with device")
 		t.updateMDNSReachability()
+	case event.ConnectionClosed:
+		t.removeScheduler(e.Conn)
 	default:
 		break
 	}
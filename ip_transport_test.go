@@ -0,0 +1,265 @@
+package hc
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/event"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/controller"
+	"github.com/brutella/hc/hap/pair"
+	"github.com/brutella/hc/service"
+	"github.com/brutella/hc/util"
+
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordConn records the written bytes.
+type recordConn struct {
+	net.Conn
+	writes []string
+	mutex  sync.Mutex
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.writes = append(c.writes, string(b))
+	return len(b), nil
+}
+
+func (c *recordConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (c *recordConn) Writes() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string{}, c.writes...)
+}
+
+func accessoriesJSON(t *testing.T, tr *ipTransport) string {
+	r, err := controller.NewContainerController(tr.container).HandleGetAccessories(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := ioutil.ReadAll(r)
+	return string(b)
+}
+
+func TestAddRemoveAccessory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	bridge := accessory.NewBridge(accessory.Info{Name: "Bridge"})
+	tr, err := NewIPTransport(Config{StoragePath: dir}, bridge.Accessory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version := tr.config.version
+
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	tr.AddAccessory(sw.Accessory)
+
+	if is, want := tr.config.version, version+1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := strings.Contains(accessoriesJSON(t, tr), `"aid":2`), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	tr.RemoveAccessory(sw.Accessory)
+
+	if is, want := tr.config.version, version+2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := strings.Contains(accessoriesJSON(t, tr), `"aid":2`), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// The bridge is not removed
+	tr.RemoveAccessory(bridge.Accessory)
+
+	if is, want := len(tr.container.Accessories), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := tr.config.version, version+2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestNoEventsAfterRemoveAccessory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	config := Config{StoragePath: dir, EventInterval: 10 * time.Millisecond, PersistValues: true, PersistDelay: time.Hour}
+	bridge := accessory.NewBridge(accessory.Info{Name: "Bridge"})
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	tr, err := NewIPTransport(config, bridge.Accessory, sw.Accessory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recordConn{}
+	conn := hap.NewConnection(rec, tr.context)
+	session := tr.context.GetSessionForConnection(conn)
+	session.SetEventsEnabled(sw.GetID(), sw.Switch.On.GetID(), true)
+
+	sw.Switch.On.SetValue(true)
+	time.Sleep(50 * time.Millisecond)
+
+	if is, want := len(rec.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	tr.values.flush()
+	tr.RemoveAccessory(sw.Accessory)
+
+	if is, want := session.EventsEnabled(sw.GetID(), sw.Switch.On.GetID()), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Events of the removed accessory are neither sent nor stored
+	session.SetEventsEnabled(sw.GetID(), sw.Switch.On.GetID(), true)
+	sw.Switch.On.SetValue(false)
+	time.Sleep(50 * time.Millisecond)
+
+	if is, want := len(rec.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := len(tr.values.pending), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestReAddAccessory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	bridge := accessory.NewBridge(accessory.Info{Name: "Bridge"})
+	sw := accessory.New(accessory.Info{Name: "Button"}, accessory.TypeProgrammableSwitch)
+	button := service.NewStatelessProgrammableSwitch()
+	sw.AddService(button.Service)
+
+	tr, err := NewIPTransport(Config{StoragePath: dir}, bridge.Accessory, sw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr.RemoveAccessory(sw)
+	tr.AddAccessory(sw)
+
+	rec := &recordConn{}
+	conn := hap.NewConnection(rec, tr.context)
+	session := tr.context.GetSessionForConnection(conn)
+	session.SetEventsEnabled(sw.GetID(), button.ProgrammableSwitchEvent.GetID(), true)
+
+	// Button presses are sent immediately, one notification per change
+	button.ProgrammableSwitchEvent.SetValue(characteristic.ProgrammableSwitchEventDoublePress)
+	if is, want := len(rec.Writes()), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	button.ProgrammableSwitchEvent.SetValue(characteristic.ProgrammableSwitchEventLongPress)
+	if is, want := len(rec.Writes()), 2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestRemoveSchedulerOfClosedConnection(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	tr, err := NewIPTransport(Config{StoragePath: dir}, sw.Accessory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := hap.NewConnection(&recordConn{}, tr.context)
+	tr.context.GetSessionForConnection(conn).SetEventsEnabled(sw.GetID(), sw.Switch.On.GetID(), true)
+	sw.Switch.On.SetValue(true)
+
+	if is, want := len(tr.schedulers), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	tr.emitter.Emit(event.ConnectionClosed{Conn: conn})
+
+	if is, want := len(tr.schedulers), 0; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestSetPinDeletesSplitVerifier(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	info := accessory.Info{Name: "Test"}
+	tr, err := NewIPTransport(Config{StoragePath: dir, Pin: "00102003"}, accessory.New(info, accessory.TypeOther))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setup, err := pair.NewSetupServerController(tr.device, tr.database, tr.setupLimiter, tr.verifierStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Transient split pair setup saves the verifier of the old pin
+	in := util.NewTLV8Container()
+	in.SetByte(pair.TagSequence, pair.PairStepStartRequest.Byte())
+	in.SetBytes(pair.TagFlags, (pair.PairingFlagTransient | pair.PairingFlagSplit).Bytes())
+	out, err := setup.Handle(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := pair.NewSetupClientSession("Pair-Setup", "001-02-003")
+	if err := client.GenerateKeys(out.GetBytes(pair.TagSalt), out.GetBytes(pair.TagPublicKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	in = util.NewTLV8Container()
+	in.SetByte(pair.TagSequence, pair.PairStepVerifyRequest.Byte())
+	in.SetBytes(pair.TagPublicKey, client.PublicKey)
+	in.SetBytes(pair.TagProof, client.Proof)
+	if out, err = setup.Handle(in); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(pair.TagErrCode), pair.ErrCodeNo.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if _, _, err := tr.verifierStore.Verifier(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr.SetPin("11122333"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Split pair setup with the old pin fails
+	in = util.NewTLV8Container()
+	in.SetByte(pair.TagSequence, pair.PairStepStartRequest.Byte())
+	in.SetBytes(pair.TagFlags, pair.PairingFlagSplit.Bytes())
+	if out, err = setup.Handle(in); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := out.GetByte(pair.TagErrCode), pair.ErrCodeAuthenticationFailed.Byte(); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,51 @@
+package hc
+
+import (
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/event"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/hap/pair"
+
+	"fmt"
+)
+
+// Pairings returns the clients which are paired with the transport.
+func (t *ipTransport) Pairings() ([]db.Pairing, error) {
+	return t.database.Pairings()
+}
+
+// Pairing returns the pairing of the client with the specified name.
+func (t *ipTransport) Pairing(name string) (db.Pairing, error) {
+	return t.database.PairingWithName(name)
+}
+
+// RemovePairing revokes the pairing of the client with the specified name.
+// When no admin is paired anymore, all pairings are removed.
+//
+// Connections of clients which are not paired anymore are closed.
+func (t *ipTransport) RemovePairing(name string) error {
+	if _, err := t.database.PairingWithName(name); err != nil {
+		return fmt.Errorf("Client %s is not paired", name)
+	}
+
+	if err := pair.RemovePairing(t.database, name); err != nil {
+		return err
+	}
+
+	hap.CloseUnpairedSessions(t.context, t.database)
+	t.emitter.Emit(event.DeviceUnpaired{})
+
+	return nil
+}
+
+// SetPairingNickname sets the nickname of the client with the specified name.
+func (t *ipTransport) SetPairingNickname(name, nickname string) error {
+	err := t.database.UpdatePairing(name, func(p *db.Pairing) {
+		p.Nickname = nickname
+	})
+	if err != nil {
+		return fmt.Errorf("Client %s is not paired", name)
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package hc
 
 import (
 	"bytes"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"math/big"
 )
 
 var invalidPins = []string{"12345678", "87654321", "00000000", "11111111", "22222222", "33333333", "44444444", "55555555", "66666666", "77777777", "88888888", "99999999"}
@@ -34,3 +36,18 @@ func NewPin(pin string) (string, error) {
 
 	return fmtPin, nil
 }
+
+// NewRandomPin returns a random 8-numbers pin string e.g. '01020304', which is a valid HomeKit pin.
+func NewRandomPin() string {
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+		if err != nil {
+			panic(err)
+		}
+
+		pin := fmt.Sprintf("%08d", n.Int64())
+		if _, err := NewPin(pin); err == nil {
+			return pin
+		}
+	}
+}
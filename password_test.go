@@ -49,3 +49,14 @@ func TestInvalidPins(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestRandomPin(t *testing.T) {
+	pin := NewRandomPin()
+	if _, err := NewPin(pin); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := len(pin), 8; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,24 @@
+// Package proxy re-exports HomeKit accessories of other HAP devices through a local transport.
+//
+// The proxy pairs as client with upstream accessories, mirrors their services and
+// characteristics into local accessories and forwards writes and events. Reads are
+// answered with the last value received from the upstream device.
+//
+//	c, _ := client.New("Proxy", database)
+//	if _, err := c.Pair("192.168.0.10:12345", "00102003"); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	p := proxy.New(c)
+//	as, err := p.Add("192.168.0.10:12345")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer p.Close()
+//
+//	bridge := accessory.NewBridge(accessory.Info{Name: "Proxy"})
+//	t, err := hc.NewIPTransport(hc.Config{}, bridge.Accessory, as...)
+//
+// When the connection to an upstream device is lost, the proxy reconnects
+// periodically. Reads and writes fail in the meantime.
+package proxy
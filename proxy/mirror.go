@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/service"
+
+	"github.com/gosexy/to"
+
+	"strings"
+)
+
+// mirror returns a local copy of the upstream accessory a. The characteristics of the copy
+// are added to u, which forwards writes and events between the copy and the upstream.
+func (u *upstream) mirror(a *accessory.Accessory) *accessory.Accessory {
+	normalizeTypes(a)
+
+	info := accessory.Info{
+		Name:             stringValue(a, characteristic.TypeName),
+		SerialNumber:     stringValue(a, characteristic.TypeSerialNumber),
+		Manufacturer:     stringValue(a, characteristic.TypeManufacturer),
+		Model:            stringValue(a, characteristic.TypeModel),
+		FirmwareRevision: stringValue(a, characteristic.TypeFirmwareRevision),
+	}
+
+	local := accessory.New(info, accessory.TypeOther)
+	services := map[int64]*service.Service{} // upstream service id to local service
+
+	for _, svc := range a.Services {
+		if svc.Type == service.TypeAccessoryInformation {
+			u.mirrorInfo(a.ID, local.Info.Service, svc)
+			services[svc.ID] = local.Info.Service
+			continue
+		}
+
+		s := service.New(svc.Type)
+		s.Hidden = svc.Hidden
+		s.Primary = svc.Primary
+		for _, c := range svc.Characteristics {
+			ch := characteristic.NewCharacteristic(c.Type)
+			copyCharacteristic(ch, c)
+			s.AddCharacteristic(ch)
+			u.add(client.ID{AccessoryID: a.ID, CharacteristicID: c.ID}, ch)
+		}
+
+		local.AddService(s)
+		services[svc.ID] = s
+	}
+
+	// The accessory ids are reassigned when the accessory is added to a container.
+	// Linked services must therefore reference the ids, which the container will assign.
+	ids := localIDs(local)
+	for _, svc := range a.Services {
+		s := services[svc.ID]
+		for _, id := range svc.Linked {
+			if linked, ok := services[id]; ok == true {
+				s.Linked = append(s.Linked, ids[linked])
+			}
+		}
+	}
+
+	return local
+}
+
+// mirrorInfo copies the characteristics of the upstream accessory information service to info.
+// Characteristics which info does not have yet are added.
+func (u *upstream) mirrorInfo(aid int64, info *service.Service, svc *service.Service) {
+	for _, c := range svc.Characteristics {
+		var ch *characteristic.Characteristic
+		for _, existing := range info.Characteristics {
+			if existing.Type == c.Type {
+				ch = existing
+				break
+			}
+		}
+
+		if ch == nil {
+			ch = characteristic.NewCharacteristic(c.Type)
+			info.AddCharacteristic(ch)
+		}
+
+		copyCharacteristic(ch, c)
+		u.add(client.ID{AccessoryID: aid, CharacteristicID: c.ID}, ch)
+	}
+}
+
+// copyCharacteristic copies the metadata and value of c to ch.
+func copyCharacteristic(ch *characteristic.Characteristic, c *characteristic.Characteristic) {
+	ch.Perms = c.Perms
+	ch.Description = c.Description
+	ch.Format = c.Format
+	ch.Unit = c.Unit
+	ch.MaxLen = c.MaxLen
+
+	// Json numbers are float64 but integer characteristics are bound by int values
+	switch c.Format {
+	case characteristic.FormatFloat:
+		ch.MinValue = floatValue(c.MinValue)
+		ch.MaxValue = floatValue(c.MaxValue)
+		ch.StepValue = floatValue(c.StepValue)
+	case characteristic.FormatUInt8, characteristic.FormatUInt16, characteristic.FormatUInt32, characteristic.FormatUInt64, characteristic.FormatInt32:
+		ch.MinValue = intValue(c.MinValue)
+		ch.MaxValue = intValue(c.MaxValue)
+		ch.StepValue = intValue(c.StepValue)
+	default:
+		ch.MinValue = c.MinValue
+		ch.MaxValue = c.MaxValue
+		ch.StepValue = c.StepValue
+	}
+
+	if c.Value != nil {
+		ch.UpdateValue(c.Value)
+	}
+}
+
+// normalizeTypes replaces the service and characteristic types of a,
+// which are Apple-defined UUIDs, with their short form e.g. "3E".
+func normalizeTypes(a *accessory.Accessory) {
+	for _, s := range a.Services {
+		s.Type = minifyUUID(s.Type)
+		for _, c := range s.Characteristics {
+			c.Type = minifyUUID(c.Type)
+		}
+	}
+}
+
+// minifyUUID returns the short form of an Apple-defined UUID.
+// For example "0000003E-0000-1000-8000-0026BB765291" is minified to "3E".
+func minifyUUID(s string) string {
+	suffix := "-0000-1000-8000-0026BB765291"
+	if strings.HasSuffix(strings.ToUpper(s), suffix) == false {
+		return s
+	}
+
+	return strings.TrimLeft(strings.ToUpper(s[:len(s)-len(suffix)]), "0")
+}
+
+// localIDs returns the ids of the services of a, after a was added to a container.
+func localIDs(a *accessory.Accessory) map[*service.Service]int64 {
+	ids := map[*service.Service]int64{}
+
+	var id int64 = 1
+	for _, s := range a.Services {
+		ids[s] = id
+		id += int64(len(s.Characteristics)) + 1
+	}
+
+	return ids
+}
+
+// stringValue returns the string value of the characteristic with type typ
+// in the accessory information service of a.
+func stringValue(a *accessory.Accessory, typ string) string {
+	for _, s := range a.Services {
+		if s.Type != service.TypeAccessoryInformation {
+			continue
+		}
+
+		for _, c := range s.Characteristics {
+			if c.Type == typ {
+				if str, ok := c.Value.(string); ok == true {
+					return str
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func floatValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return to.Float64(v)
+}
+
+func intValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return int(to.Int64(v))
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/service"
+
+	"reflect"
+	"testing"
+)
+
+func TestMirrorLinkedServices(t *testing.T) {
+	a := accessory.NewOutlet(accessory.Info{Name: "Outlet"})
+	fan := service.NewFan()
+	a.AddService(fan.Service)
+	a.UpdateIDs()
+	a.Outlet.AddLinkedService(fan.Service)
+
+	// Upstream ids are not sequential
+	a.SetID(5)
+	fan.SetID(100)
+	a.Outlet.Linked = []int64{100}
+
+	u := &upstream{
+		ids:   map[*characteristic.Characteristic]client.ID{},
+		chars: map[client.ID]*characteristic.Characteristic{},
+	}
+	local := u.mirror(a.Accessory)
+
+	container := accessory.NewContainer()
+	container.AddAccessory(local)
+
+	if is, want := local.Services[1].Linked, []int64{local.Services[2].ID}; reflect.DeepEqual(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	id := client.ID{AccessoryID: 5, CharacteristicID: a.Outlet.On.ID}
+	if is, want := u.chars[id], local.Services[1].Characteristics[0]; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestMinifyUUID(t *testing.T) {
+	if is, want := minifyUUID("0000003E-0000-1000-8000-0026BB765291"), "3E"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := minifyUUID("25"), "25"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
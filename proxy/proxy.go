@@ -0,0 +1,279 @@
+package proxy
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/hap/data"
+	"github.com/brutella/hc/log"
+
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultRetryInterval is the default interval after which the proxy tries to reconnect to an upstream device.
+const DefaultRetryInterval = 10 * time.Second
+
+// Proxy mirrors the accessories of upstream HomeKit devices.
+type Proxy struct {
+	// Interval after which the proxy tries to reconnect to an upstream device
+	RetryInterval time.Duration
+
+	client    *client.Client
+	mutex     *sync.Mutex
+	upstreams []*upstream
+}
+
+// New returns a proxy which connects to upstream devices with the client c.
+// The client must be paired with the upstream devices.
+func New(c *client.Client) *Proxy {
+	return &Proxy{
+		RetryInterval: DefaultRetryInterval,
+		client:        c,
+		mutex:         &sync.Mutex{},
+	}
+}
+
+// Add connects to the upstream device at addr (host:port) and returns local copies of its accessories.
+// The accessories should be published through a transport. If the upstream device is a bridge,
+// the bridge accessory itself is not mirrored.
+func (p *Proxy) Add(addr string) ([]*accessory.Accessory, error) {
+	s, err := p.client.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := s.Accessories()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	u := &upstream{
+		addr:   addr,
+		client: p.client,
+		retry:  p.RetryInterval,
+		mutex:  &sync.Mutex{},
+		ids:    map[*characteristic.Characteristic]client.ID{},
+		chars:  map[client.ID]*characteristic.Characteristic{},
+		stop:   make(chan struct{}),
+	}
+
+	var locals []*accessory.Accessory
+	for _, a := range as {
+		// The first accessory of a bridge represents the bridge
+		if len(as) > 1 && a.ID == 1 {
+			continue
+		}
+		locals = append(locals, u.mirror(a))
+	}
+
+	if err := u.connect(s); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	go u.run()
+
+	p.mutex.Lock()
+	p.upstreams = append(p.upstreams, u)
+	p.mutex.Unlock()
+
+	return locals, nil
+}
+
+// Close closes the connections to the upstream devices.
+func (p *Proxy) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, u := range p.upstreams {
+		u.close()
+	}
+	p.upstreams = nil
+}
+
+// upstream forwards writes and events between local characteristics
+// and the characteristics of an upstream device.
+type upstream struct {
+	addr   string
+	client *client.Client
+	retry  time.Duration
+
+	mutex   *sync.Mutex
+	session *client.Session // nil while disconnected
+
+	ids   map[*characteristic.Characteristic]client.ID
+	chars map[client.ID]*characteristic.Characteristic
+
+	stop chan struct{}
+}
+
+// add forwards writes of the local characteristic ch to the upstream characteristic with id.
+func (u *upstream) add(id client.ID, ch *characteristic.Characteristic) {
+	u.ids[ch] = id
+	u.chars[id] = ch
+
+	// Reads are called while the transport is locked and must not block.
+	// The local value is kept up to date by upstream events instead.
+	ch.OnValueRead(func(conn net.Conn, c *characteristic.Characteristic) (interface{}, error) {
+		if u.connected() == false {
+			return nil, characteristic.ErrCommunicationFailure
+		}
+
+		return c.Value, nil
+	})
+
+	ch.OnValueWrite(func(conn net.Conn, c *characteristic.Characteristic, value interface{}) error {
+		s := u.currentSession()
+		if s == nil {
+			return characteristic.ErrCommunicationFailure
+		}
+
+		if err := s.SetValue(id, value); err != nil {
+			log.Debug.Printf("Writing %s to %s failed: %v\n", id, u.addr, err)
+			return characteristic.ErrCommunicationFailure
+		}
+
+		return nil
+	})
+}
+
+// connect subscribes to events of s and updates the local characteristics
+// with the current upstream values.
+func (u *upstream) connect(s *client.Session) error {
+	s.OnEvent(u.handleEvent)
+
+	var events []client.ID
+	var reads []client.ID
+	for ch, id := range u.ids {
+		if ch.SupportsEvents() == true {
+			events = append(events, id)
+		}
+
+		if hasPerm(ch, characteristic.PermRead) == true {
+			reads = append(reads, id)
+		}
+	}
+
+	if len(events) > 0 {
+		if err := s.Subscribe(events...); err != nil {
+			return err
+		}
+	}
+
+	if len(reads) > 0 {
+		chs, err := s.GetCharacteristics(reads...)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range chs {
+			if c.Status == nil {
+				u.handleEvent(c)
+			}
+		}
+	}
+
+	u.mutex.Lock()
+	u.session = s
+	u.mutex.Unlock()
+
+	return nil
+}
+
+// run reconnects to the upstream device when the connection is lost.
+func (u *upstream) run() {
+	for {
+		s := u.currentSession()
+		select {
+		case <-u.stop:
+			return
+		case <-s.Done():
+		}
+
+		log.Info.Printf("Connection to %s lost\n", u.addr)
+		u.mutex.Lock()
+		u.session = nil
+		u.mutex.Unlock()
+
+		for u.currentSession() == nil {
+			select {
+			case <-u.stop:
+				return
+			case <-time.After(u.retry):
+			}
+
+			s, err := u.client.Dial(u.addr)
+			if err != nil {
+				log.Debug.Printf("Reconnecting to %s failed: %v\n", u.addr, err)
+				continue
+			}
+
+			if err := u.connect(s); err != nil {
+				log.Debug.Printf("Reconnecting to %s failed: %v\n", u.addr, err)
+				s.Close()
+				continue
+			}
+
+			log.Info.Printf("Reconnected to %s\n", u.addr)
+		}
+
+		select {
+		case <-u.stop:
+			// The proxy was closed while reconnecting
+			u.currentSession().Close()
+			return
+		default:
+		}
+	}
+}
+
+// handleEvent updates the local characteristic with the value of the upstream characteristic c.
+func (u *upstream) handleEvent(c data.Characteristic) {
+	if ch, ok := u.chars[client.ID{AccessoryID: c.AccessoryID, CharacteristicID: c.CharacteristicID}]; ok == true {
+		ch.UpdateValue(c.Value)
+	}
+}
+
+func (u *upstream) currentSession() *client.Session {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	return u.session
+}
+
+// connected returns true when the session to the upstream device is open.
+func (u *upstream) connected() bool {
+	s := u.currentSession()
+	if s == nil {
+		return false
+	}
+
+	select {
+	case <-s.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+func (u *upstream) close() {
+	close(u.stop)
+
+	if s := u.currentSession(); s != nil {
+		s.Close()
+	}
+}
+
+// hasPerm returns true when ch has the permission perm.
+func hasPerm(ch *characteristic.Characteristic, perm string) bool {
+	for _, p := range ch.Perms {
+		if p == perm {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,167 @@
+package proxy
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/client"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/internal/testserver"
+
+	"context"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T, name, addr string) *client.Client {
+	database, _ := db.NewTempDatabase()
+	c, err := client.New(name, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Pair(addr, testserver.Pin); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// sendEvent sends the value of the switch to the clients of the server.
+func sendEvent(ctx hap.Context, sw *accessory.Switch) {
+	b, _ := hap.NewCharacteristicNotification(sw.Accessory, sw.Switch.On.Characteristic)
+	for _, conn := range ctx.ActiveConnections() {
+		conn.(*hap.Connection).WriteEvent(b)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch", Manufacturer: "Upstream"})
+	up := testserver.Start(t, "Upstream", sw.Accessory)
+	defer up.Stop()
+
+	p := New(newClient(t, "Proxy", up.Addr))
+	p.RetryInterval = 10 * time.Millisecond
+	defer p.Close()
+
+	as, err := p.Add(up.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := len(as), 1; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	mirrored := as[0]
+	if is, want := mirrored.Info.Name.GetValue(), "Switch"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+	if is, want := mirrored.Info.Manufacturer.GetValue(), "Upstream"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	bridge := accessory.NewBridge(accessory.Info{Name: "Bridge"})
+	srv := testserver.Start(t, "Bridge", bridge.Accessory, mirrored)
+	defer srv.Stop()
+
+	s, err := newClient(t, "Controller", srv.Addr).Dial(srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	on := mirrored.Services[1].Characteristics[0]
+	id := client.ID{AccessoryID: mirrored.ID, CharacteristicID: on.ID}
+
+	// Write is forwarded to upstream
+	if err := s.SetValue(id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := sw.Switch.On.GetValue(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Events are forwarded from upstream
+	updates := valueUpdates(on)
+	sw.Switch.On.SetValue(false)
+	sendEvent(up.Context, sw)
+
+	if err := waitForUpdate(updates, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read returns the value received from upstream
+	chs, err := s.GetCharacteristics(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := chs[0].Value, false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	up := testserver.Start(t, "Upstream", sw.Accessory)
+	defer up.Stop()
+
+	p := New(newClient(t, "Proxy", up.Addr))
+	p.RetryInterval = 10 * time.Millisecond
+	defer p.Close()
+
+	as, err := p.Add(up.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a lost connection
+	u := p.upstreams[0]
+	u.currentSession().Close()
+
+	on := as[0].Services[1].Characteristics[0]
+	if _, err := on.ReadValueFromConnection(nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	for i := 0; u.currentSession() == nil; i++ {
+		if i == 100 {
+			t.Fatal("not reconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Events are received again after reconnecting
+	updates := valueUpdates(on)
+	sw.Switch.On.SetValue(true)
+	sendEvent(up.Context, sw)
+
+	if err := waitForUpdate(updates, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// valueUpdates returns a channel which receives the new values of c.
+func valueUpdates(c *characteristic.Characteristic) <-chan interface{} {
+	ch := make(chan interface{}, 10)
+	c.OnValueUpdate(func(c *characteristic.Characteristic, new, old interface{}) {
+		ch <- new
+	})
+
+	return ch
+}
+
+func waitForUpdate(ch <-chan interface{}, value interface{}) error {
+	for {
+		select {
+		case v := <-ch:
+			if v == value {
+				return nil
+			}
+		case <-time.After(time.Second):
+			return context.DeadlineExceeded
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package qrcode implements a QR code encoder for short texts like the HomeKit setup payload.
+//
+// The code is rendered as text, with ANSI colors for terminals or as PNG image.
+package qrcode
@@ -0,0 +1,288 @@
+package qrcode
+
+// matrix is used to draw a QR code.
+type matrix struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // true for modules of function patterns
+}
+
+func newMatrix(v version) *matrix {
+	size := v.number*4 + 17
+	m := &matrix{
+		size:     size,
+		modules:  make([][]bool, size),
+		function: make([][]bool, size),
+	}
+
+	for i := 0; i < size; i++ {
+		m.modules[i] = make([]bool, size)
+		m.function[i] = make([]bool, size)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(size-4, 3)
+	m.drawFinder(3, size-4)
+
+	// Timing patterns
+	for i := 0; i < size; i++ {
+		if m.function[6][i] == false {
+			m.set(i, 6, i%2 == 0)
+		}
+		if m.function[i][6] == false {
+			m.set(6, i, i%2 == 0)
+		}
+	}
+
+	if v.alignment > 0 {
+		m.drawAlignment(v.alignment, v.alignment)
+	}
+
+	// Reserve format information, which is drawn after masking
+	m.drawFormat(0)
+
+	return m
+}
+
+// Code returns the QR code of the matrix.
+func (m *matrix) Code() *Code {
+	modules := make([][]bool, m.size)
+	for i, row := range m.modules {
+		modules[i] = append([]bool{}, row...)
+	}
+
+	return &Code{Size: m.size, modules: modules}
+}
+
+// set sets the module at column x and row y as part of a function pattern.
+func (m *matrix) set(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.function[y][x] = true
+}
+
+// drawFinder draws a finder pattern including its separator around the center x, y.
+func (m *matrix) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= m.size || yy >= m.size {
+				continue
+			}
+
+			dist := max(abs(dx), abs(dy))
+			m.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern around the center x, y.
+func (m *matrix) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat draws the format information for error correction level M and mask.
+func (m *matrix) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 == 1
+	}
+
+	// First copy around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+
+	// Second copy next to the other finder patterns
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+
+	// Dark module
+	m.set(8, m.size-8, true)
+}
+
+// formatBits returns the 15 bits of the format information for error correction level M and mask.
+func formatBits(mask int) int {
+	data := 0x0<<3 | mask // level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// placeCodewords places the codewords in the modules, which are not part of a function pattern.
+func (m *matrix) placeCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				upward := (right+1)&2 == 0
+				y := vert
+				if upward == true {
+					y = m.size - 1 - vert
+				}
+
+				// Remainder bits stay light
+				if m.function[y][x] == false && i < len(codewords)*8 {
+					m.modules[y][x] = (codewords[i/8]>>uint(7-i%8))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the modules, which are not part of a function pattern, based on the mask pattern.
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.function[y][x] == true {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+
+			if invert == true {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty returns the penalty score of the matrix, which is used to choose the best mask.
+func (m *matrix) penalty() int {
+	result := 0
+	dark := 0
+
+	// Runs of modules of the same color and finder-like patterns in rows and columns
+	for i := 0; i < m.size; i++ {
+		row := make([]bool, m.size)
+		col := make([]bool, m.size)
+		for j := 0; j < m.size; j++ {
+			row[j] = m.modules[i][j]
+			col[j] = m.modules[j][i]
+			if row[j] == true {
+				dark++
+			}
+		}
+
+		result += runPenalty(row) + runPenalty(col)
+		result += finderPenalty(row) + finderPenalty(col)
+	}
+
+	// Blocks of 2x2 modules of the same color
+	for y := 0; y < m.size-1; y++ {
+		for x := 0; x < m.size-1; x++ {
+			c := m.modules[y][x]
+			if c == m.modules[y][x+1] && c == m.modules[y+1][x] && c == m.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+
+	// Balance of dark and light modules
+	total := m.size * m.size
+	percent := dark * 100 / total
+	result += abs(percent-50) / 5 * 10
+
+	return result
+}
+
+func runPenalty(line []bool) int {
+	result := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+
+		if run >= 5 {
+			result += 3 + run - 5
+		}
+		run = 1
+	}
+
+	return result
+}
+
+var finderLike = []bool{true, false, true, true, true, false, true, false, false, false, false}
+
+func finderPenalty(line []bool) int {
+	result := 0
+	n := len(finderLike)
+	for i := 0; i+n <= len(line); i++ {
+		forward, backward := true, true
+		for j := 0; j < n; j++ {
+			if line[i+j] != finderLike[j] {
+				forward = false
+			}
+			if line[i+j] != finderLike[n-1-j] {
+				backward = false
+			}
+		}
+
+		if forward == true {
+			result += 40
+		}
+		if backward == true {
+			result += 40
+		}
+	}
+
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,188 @@
+package qrcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTooLong is returned when the text does not fit into a supported QR code version.
+var ErrTooLong = errors.New("Text is too long for a QR code")
+
+// Code is a QR code symbol.
+type Code struct {
+	// Size is the number of modules per side.
+	Size int
+
+	modules [][]bool // true for dark modules
+}
+
+// Dark returns true when the module at column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+
+	return c.modules[y][x]
+}
+
+// version describes the error correction blocks of a QR code version at error correction level M.
+type version struct {
+	number    int
+	blocks    int // number of error correction blocks
+	dataCodes int // data codewords per block
+	ecCodes   int // error correction codewords per block
+	alignment int // position of the alignment pattern, 0 if none
+}
+
+// versions are the supported versions at error correction level M.
+var versions = []version{
+	{number: 1, blocks: 1, dataCodes: 16, ecCodes: 10, alignment: 0},
+	{number: 2, blocks: 1, dataCodes: 28, ecCodes: 16, alignment: 18},
+	{number: 3, blocks: 1, dataCodes: 44, ecCodes: 26, alignment: 22},
+	{number: 4, blocks: 2, dataCodes: 32, ecCodes: 18, alignment: 26},
+}
+
+// alphanumeric contains the characters of the alphanumeric mode.
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// Encode returns the QR code for text with error correction level M.
+// Text consisting of uppercase letters, digits and " $%*+-./:" is encoded in alphanumeric mode,
+// any other text in byte mode.
+func Encode(text string) (*Code, error) {
+	alnum := isAlphanumeric(text)
+
+	for _, v := range versions {
+		bits := encodeData(text, alnum)
+		capacity := v.blocks * v.dataCodes * 8
+		if bits.len() > capacity {
+			continue
+		}
+
+		data := bits.codewords(capacity)
+		codewords := interleave(data, v)
+
+		best := -1
+		var code *Code
+		for mask := 0; mask < 8; mask++ {
+			c := newMatrix(v)
+			c.placeCodewords(codewords)
+			c.applyMask(mask)
+			c.drawFormat(mask)
+
+			if p := c.penalty(); best < 0 || p < best {
+				best = p
+				code = c.Code()
+			}
+		}
+
+		return code, nil
+	}
+
+	return nil, ErrTooLong
+}
+
+func isAlphanumeric(text string) bool {
+	for _, r := range text {
+		if strings.ContainsRune(alphanumeric, r) == false {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeData returns the bits of the mode indicator, character count and data.
+func encodeData(text string, alnum bool) *bitBuffer {
+	b := &bitBuffer{}
+
+	if alnum == true {
+		b.append(0x2, 4)
+		b.append(len(text), 9)
+		for i := 0; i < len(text); i += 2 {
+			v := strings.IndexByte(alphanumeric, text[i])
+			if i+1 < len(text) {
+				v = v*45 + strings.IndexByte(alphanumeric, text[i+1])
+				b.append(v, 11)
+			} else {
+				b.append(v, 6)
+			}
+		}
+	} else {
+		b.append(0x4, 4)
+		b.append(len(text), 8)
+		for i := 0; i < len(text); i++ {
+			b.append(int(text[i]), 8)
+		}
+	}
+
+	return b
+}
+
+// interleave splits data into blocks, computes the error correction codewords
+// and returns the interleaved codewords.
+func interleave(data []byte, v version) []byte {
+	var dataBlocks, ecBlocks [][]byte
+	for i := 0; i < v.blocks; i++ {
+		block := data[i*v.dataCodes : (i+1)*v.dataCodes]
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, reedSolomon(block, v.ecCodes))
+	}
+
+	var result []byte
+	for i := 0; i < v.dataCodes; i++ {
+		for _, block := range dataBlocks {
+			result = append(result, block[i])
+		}
+	}
+
+	for i := 0; i < v.ecCodes; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+
+	return result
+}
+
+// bitBuffer is a sequence of bits.
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>uint(i))&1 == 1)
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+// codewords returns the bytes of the buffer including terminator and pad bytes
+// to fill capacity bits.
+func (b *bitBuffer) codewords(capacity int) []byte {
+	terminator := capacity - b.len()
+	if terminator > 4 {
+		terminator = 4
+	}
+	b.append(0, terminator)
+
+	if r := b.len() % 8; r != 0 {
+		b.append(0, 8-r)
+	}
+
+	pad := []int{0xEC, 0x11}
+	for i := 0; b.len() < capacity; i++ {
+		b.append(pad[i%2], 8)
+	}
+
+	result := make([]byte, capacity/8)
+	for i, bit := range b.bits {
+		if bit == true {
+			result[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,178 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodewords(t *testing.T) {
+	// Example from https://www.thonky.com/qr-code-tutorial/
+	data := encodeData("HELLO WORLD", true).codewords(16 * 8)
+	if is, want := data, []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}; reflect.DeepEqual(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := reedSolomon(data, 10), []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}; reflect.DeepEqual(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	if is, want := fmt.Sprintf("%015b", formatBits(0)), "101010000010010"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := fmt.Sprintf("%015b", formatBits(7)), "100101010100000"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	c, err := Encode("X-HM://0023ISYWY1ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := c.Size, 21; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	// Finder pattern at the top left corner
+	for i := 0; i < 7; i++ {
+		if c.Dark(i, 0) == false || c.Dark(0, i) == false || c.Dark(i, 6) == false || c.Dark(6, i) == false {
+			t.Fatal("invalid finder pattern")
+		}
+	}
+
+	if c.Dark(7, 7) == true || c.Dark(3, 3) == false {
+		t.Fatal("invalid finder pattern")
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	c, err := Encode(strings.Repeat("a", 60))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := c.Size, 33; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if _, err := Encode(strings.Repeat("a", 100)); err != ErrTooLong {
+		t.Fatal(err)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	c, _ := Encode("HELLO WORLD")
+
+	var b bytes.Buffer
+	if err := c.WritePNG(&b, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := img.Bounds().Dx(), (21+8)*2; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+// golden fails when the modules of c differ from rows, in which dark modules are "#" and light modules ".".
+func golden(t *testing.T, c *Code, rows []string) {
+	if is, want := c.Size, len(rows); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	for y, row := range rows {
+		for x, m := range row {
+			if is, want := c.Dark(x, y), m == '#'; is != want {
+				t.Fatalf("module at %d,%d is=%v want=%v", x, y, is, want)
+			}
+		}
+	}
+}
+
+// The expected modules were generated with github.com/skip2/go-qrcode at level Medium.
+func TestEncodeGolden(t *testing.T) {
+	c, err := Encode("X-HM://0023ISYWY1ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, c, []string{
+		"#######....##.#######",
+		"#.....#....#..#.....#",
+		"#.###.#.####..#.###.#",
+		"#.###.#.###...#.###.#",
+		"#.###.#.#.#.#.#.###.#",
+		"#.....#.#...#.#.....#",
+		"#######.#.#.#.#######",
+		"........#............",
+		"#.#####....##.#####..",
+		"##..#..#####.....##.#",
+		"###..##.#..#.###...#.",
+		"##..##...###...##.###",
+		"#...#.##.#..####...##",
+		"........####.#.#.###.",
+		"#######..#.##..#.#.#.",
+		"#.....#.####...#.#..#",
+		"#.###.#.##..######..#",
+		"#.###.#.##..#.#.#....",
+		"#.###.#.#.#.#.#..##..",
+		"#.....#..#.#####.#..#",
+		"#######.#.#.#.#.##...",
+	})
+}
+
+// Tests byte mode and the alignment pattern with a fixed mask, because
+// github.com/skip2/go-qrcode chooses masks with a different penalty score.
+func TestMatrixGolden(t *testing.T) {
+	v := versions[2]
+	data := encodeData("https://github.com/brutella/hc", false).codewords(v.blocks * v.dataCodes * 8)
+
+	m := newMatrix(v)
+	m.placeCodewords(interleave(data, v))
+	m.applyMask(6)
+	m.drawFormat(6)
+
+	golden(t, m.Code(), []string{
+		"#######.#.#.#..###....#######",
+		"#.....#.#...#...#..#..#.....#",
+		"#.###.#.#####..##.###.#.###.#",
+		"#.###.#....#....##....#.###.#",
+		"#.###.#.#.#.#.######..#.###.#",
+		"#.....#..#.##..###....#.....#",
+		"#######.#.#.#.#.#.#.#.#######",
+		".........##..#.##...#........",
+		"#..#######.###...#.###..#.###",
+		"#....#.##.#..#..####.#.##.##.",
+		"###..##....#.#.###..#..##.#..",
+		".##.#..#.....#..####.###.#..#",
+		".###..#..##.##.##..##.##....#",
+		".#...#..###..##.###...#######",
+		".##.###.#..###..#..####.#.#.#",
+		"##.#....#.###..#..##...##.#.#",
+		"####..##.####.#.#..###...#...",
+		"##.#...#..#..##.####....#.##.",
+		"###...#.##.....#.###....##..#",
+		"###..#....#...#....#..#..##..",
+		"###...#..##.#######.########.",
+		"........#.#...#..##.#...##...",
+		"#######.#..#..#....##.#.##...",
+		"#.....#.#.###.##.#.##...#....",
+		"#.###.#.#.##.##.#..#######...",
+		"#.###.#.#.###....##..#.....#.",
+		"#.###.#....###.#..#.##.##.###",
+		"#.....#..#.#...##.#...##.##.#",
+		"#######.##...#.#...##.#.#....",
+	})
+}
@@ -0,0 +1,49 @@
+package qrcode
+
+// reedSolomon returns n error correction codewords for data.
+func reedSolomon(data []byte, n int) []byte {
+	divisor := rsDivisor(n)
+
+	result := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[n-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+
+	return result
+}
+
+// rsDivisor returns the coefficients of the generator polynomial of degree n
+// from highest to lowest power, without the leading coefficient 1.
+func rsDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+
+	var root byte = 1
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// gfMultiply returns the product of x and y in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+
+	return byte(z)
+}
@@ -0,0 +1,86 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// quietZone is the number of light modules around the code.
+const quietZone = 4
+
+// String returns the code as ASCII text, where two "#" represent a dark module.
+func (c *Code) String() string {
+	var b bytes.Buffer
+	for y := -quietZone; y < c.Size+quietZone; y++ {
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			if c.Dark(x, y) == true {
+				b.WriteString("##")
+			} else {
+				b.WriteString("  ")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// WriteTerminal writes the code to w using ANSI background colors.
+// The code is readable on terminals with light and dark backgrounds.
+func (c *Code) WriteTerminal(w io.Writer) error {
+	const (
+		dark  = "\x1b[40m  "
+		light = "\x1b[47m  "
+		reset = "\x1b[0m"
+	)
+
+	var b bytes.Buffer
+	for y := -quietZone; y < c.Size+quietZone; y++ {
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			if c.Dark(x, y) == true {
+				b.WriteString(dark)
+			} else {
+				b.WriteString(light)
+			}
+		}
+		b.WriteString(reset + "\n")
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Image returns an image of the code, where every module has scale x scale pixels.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+
+	size := (c.Size + 2*quietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			x, y := px/scale-quietZone, py/scale-quietZone
+			if c.Dark(x, y) == true {
+				img.SetGray(px, py, color.Gray{Y: 0x00})
+			} else {
+				img.SetGray(px, py, color.Gray{Y: 0xFF})
+			}
+		}
+	}
+
+	return img
+}
+
+// WritePNG writes the code as PNG image to w, where every module has scale x scale pixels.
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	if err := png.Encode(w, c.Image(scale)); err != nil {
+		return fmt.Errorf("Could not encode QR code as PNG: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package hc
+
+import (
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/event"
+	"github.com/brutella/hc/hap"
+	"github.com/brutella/hc/log"
+	"github.com/brutella/hc/util"
+)
+
+// Reset resets the transport to factory settings.
+//
+// All pairings are removed and the transport gets a new accessory id and long-term key pair.
+// The configuration number (c#) and config hash are reset. The accessory becomes discoverable
+// and the new identity is announced via mDNS, so iOS clients see it as a new accessory.
+// Active connections are closed.
+//
+// The pin and setup id are kept. Reset can be called on a running or stopped transport.
+func (t *ipTransport) Reset() error {
+	pairings, err := t.database.Pairings()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pairings {
+		log.Debug.Printf("Remove pairing of client '%s'\n", p.Name)
+		t.database.DeletePairing(p.Name)
+	}
+
+	// Generate new accessory id and key pair
+	id := util.MAC48Address(util.RandomHexString())
+	device, err := hap.NewDevice(id, t.database)
+	if err != nil {
+		return err
+	}
+
+	old := t.device.Name()
+	t.device.SetDevice(device)
+	t.database.DeleteEntity(db.Entity{Name: old})
+	log.Info.Printf("Reset accessory id %s to %s\n", old, id)
+
+	t.setupLimiter.Reset()
+	t.setupLimiter.ClosePairing()
+	t.verifierStore.Delete()
+
+	for _, conn := range t.context.ActiveConnections() {
+		if c, ok := conn.(*hap.Connection); ok == true {
+			c.CloseWhenInactive()
+		} else {
+			conn.Close()
+		}
+	}
+
+	t.mutex.Lock()
+	t.config.id = id
+	t.config.version = 1
+	t.config.configHash = nil
+	t.config.discoverable = true
+	t.mutex.Unlock()
+
+	t.updateConfig()
+	t.updateMDNSText()
+
+	t.emitter.Emit(event.DeviceUnpaired{})
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package hc
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/db"
+
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestReset(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	info := accessory.Info{Name: "Test"}
+	tr, err := NewIPTransport(Config{StoragePath: dir}, accessory.New(info, accessory.TypeOther))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr.database.SavePairing(db.NewPairing("Client", []byte{0x01}, db.PermissionAdmin))
+	tr.config.version = 5
+	tr.config.discoverable = false
+
+	id := tr.config.id
+	publicKey := tr.device.PublicKey()
+
+	if err := tr.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ps, _ := tr.Pairings(); len(ps) != 0 {
+		t.Fatal(ps)
+	}
+
+	if tr.config.id == id {
+		t.Fatal("id not changed")
+	}
+
+	if is, want := tr.device.Name(), tr.config.id; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if string(tr.device.PublicKey()) == string(publicKey) {
+		t.Fatal("key pair not changed")
+	}
+
+	if is, want := tr.config.version, int64(1); is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if is, want := tr.config.discoverable, true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	if _, err := tr.database.EntityWithName(id); err == nil {
+		t.Fatal("expected old key pair to be deleted")
+	}
+
+	// The new identity is loaded by a new transport
+	cfg := defaultConfig("Test")
+	cfg.load(tr.storage)
+	if is, want := cfg.id, tr.config.id; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,94 @@
+package hc
+
+import (
+	"github.com/brutella/hc/qrcode"
+
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// setupFlagIP is the flag of the setup payload for accessories, which are paired over IP
+const setupFlagIP = 2
+
+// setupIdChars are the characters of a setup id
+const setupIdChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// newSetupId returns a random setup id of 4 characters.
+func newSetupId() string {
+	var b [4]byte
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(setupIdChars))))
+		if err != nil {
+			panic(err)
+		}
+		b[i] = setupIdChars[n.Int64()]
+	}
+
+	return string(b[:])
+}
+
+// validateSetupId returns an error when id is not a valid setup id.
+func validateSetupId(id string) error {
+	if len(id) != 4 {
+		return fmt.Errorf("Setup id %s must have 4 characters", id)
+	}
+
+	for _, r := range id {
+		if strings.ContainsRune(setupIdChars, r) == false {
+			return fmt.Errorf("Setup id %s must only contain digits and uppercase letters", id)
+		}
+	}
+
+	return nil
+}
+
+// setupHash returns the setup hash (sh) published in the mDNS txt records.
+// The hash is used by iOS to find the accessory after scanning the setup payload.
+func setupHash(setupId, deviceId string) string {
+	h := sha512.Sum512([]byte(setupId + deviceId))
+	return base64.StdEncoding.EncodeToString(h[:4])
+}
+
+// xhmURI returns the setup payload e.g. "X-HM://0023ISYWYABCD" for an accessory
+// with a pin of format "00102003", setup id, category and setup flags.
+func xhmURI(pin, setupId string, category int, flags int) (string, error) {
+	code, err := strconv.ParseUint(strings.Replace(pin, "-", "", -1), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("Invalid pin %s: %v", pin, err)
+	}
+
+	// Bits 0-26 setup code, 27-30 flags, 31-38 category, 39-42 reserved, 43-45 version
+	var payload uint64
+	payload |= code & 0x7FFFFFF
+	payload |= uint64(flags&0xF) << 27
+	payload |= uint64(category&0xFF) << 31
+
+	encoded := strings.ToUpper(strconv.FormatUint(payload, 36))
+	for len(encoded) < 9 {
+		encoded = "0" + encoded
+	}
+
+	return "X-HM://" + encoded + setupId, nil
+}
+
+// XHMURI returns the setup payload of the transport, which is encoded
+// in the QR code to pair with the accessory.
+func (t *ipTransport) XHMURI() (string, error) {
+	return xhmURI(t.Pin(), t.config.SetupId, t.config.categoryId, setupFlagIP)
+}
+
+// QRCode returns the QR code of the setup payload.
+// The code can be printed to a terminal or saved as PNG image.
+func (t *ipTransport) QRCode() (*qrcode.Code, error) {
+	uri, err := t.XHMURI()
+	if err != nil {
+		return nil, err
+	}
+
+	return qrcode.Encode(uri)
+}
@@ -0,0 +1,37 @@
+package hc
+
+import (
+	"testing"
+)
+
+func TestXHMURI(t *testing.T) {
+	uri, err := xhmURI("00102003", "7OSX", 5, setupFlagIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := uri, "X-HM://00520NTRN7OSX"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+
+	uri, _ = xhmURI("031-45-154", "ABCD", 2, setupFlagIP)
+	if is, want := uri, "X-HM://0023ISYWYABCD"; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestSetupHash(t *testing.T) {
+	if is, want := setupHash("7OSX", "AA:BB:CC:DD:EE:FF"), "XIonQA=="; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestSetupId(t *testing.T) {
+	if err := validateSetupId(newSetupId()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateSetupId("abcd"); err == nil {
+		t.Fatal("expected error")
+	}
+}
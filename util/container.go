@@ -16,6 +16,9 @@ type Container interface {
 	// SetString sets a string for a key
 	SetString(key byte, value string)
 
+	// SetSeparator adds an empty value for a key, which separates items in a list
+	SetSeparator(key byte)
+
 	// GetByte returns one byte for a key
 	GetByte(key byte) byte
 
@@ -30,6 +30,25 @@ func TestFileStorage(t *testing.T) {
 	}
 }
 
+func TestOverwriteWithShorterValue(t *testing.T) {
+	storage, err := NewTempFileStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.Set("test", []byte("ASDF"))
+	storage.Set("test", []byte("AS"))
+
+	read, err := storage.Get("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := read, []byte("AS"); reflect.DeepEqual(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
 func TestStoreInSubdirectory(t *testing.T) {
 	dir, _ := filepath.Abs(filepath.Join(os.TempDir(), "hap"))
 	storage, err := NewFileStorage(dir)
@@ -104,6 +104,10 @@ func (t *tlv8Container) SetByte(tag uint8, b byte) {
 	t.SetBytes(tag, []byte{b})
 }
 
+func (t *tlv8Container) SetSeparator(tag uint8) {
+	t.Items = append(t.Items, tlv8{tag: tag, length: 0, value: []byte{}})
+}
+
 func (t *tlv8Container) BytesBuffer() *bytes.Buffer {
 	var b bytes.Buffer
 	for _, item := range t.Items {
@@ -91,3 +91,14 @@ func TestTLV8String(t *testing.T) {
 		t.Fatalf("is=%v want=%v", is, want)
 	}
 }
+
+func TestTLV8Separator(t *testing.T) {
+	container := NewTLV8Container()
+	container.SetByte(1, 0xAF)
+	container.SetSeparator(0xFF)
+	container.SetByte(1, 0xFA)
+
+	if is, want := container.BytesBuffer().Bytes(), []byte{0x01, 0x01, 0xAF, 0xFF, 0x00, 0x01, 0x01, 0xFA}; reflect.DeepEqual(is, want) == false {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
@@ -0,0 +1,132 @@
+package hc
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/log"
+	"github.com/brutella/hc/util"
+
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// storedValue is the value of a characteristic in the storage.
+// The type is stored to ignore values of characteristics whose ids changed.
+type storedValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// valueStore stores the values of writable characteristics.
+// Changed values are written after a delay, which coalesces frequent changes.
+type valueStore struct {
+	storage util.Storage
+	delay   time.Duration
+
+	mutex   *sync.Mutex
+	pending map[string][]byte
+	timer   *time.Timer
+}
+
+func newValueStore(storage util.Storage, delay time.Duration) *valueStore {
+	return &valueStore{
+		storage: storage,
+		delay:   delay,
+		mutex:   &sync.Mutex{},
+		pending: map[string][]byte{},
+	}
+}
+
+// restore sets the stored values of the writable characteristics of a.
+// The accessory must already have its ids assigned.
+func (s *valueStore) restore(a *accessory.Accessory) {
+	for _, svc := range a.Services {
+		for _, c := range svc.Characteristics {
+			if persistent(c) == false {
+				continue
+			}
+
+			b, err := s.storage.Get(valueKey(a, c))
+			if err != nil || len(b) == 0 {
+				continue
+			}
+
+			var v storedValue
+			if err := json.Unmarshal(b, &v); err != nil {
+				log.Info.Println(err)
+				continue
+			}
+
+			if v.Type != c.Type || v.Value == nil {
+				continue
+			}
+
+			c.UpdateValue(v.Value)
+		}
+	}
+}
+
+// store schedules to store value of the characteristic c of a, if c is writable.
+func (s *valueStore) store(a *accessory.Accessory, c *characteristic.Characteristic, value interface{}) {
+	if persistent(c) == true {
+		s.set(valueKey(a, c), c.Type, value)
+	}
+}
+
+// set schedules to store the value for key.
+func (s *valueStore) set(key, typ string, value interface{}) {
+	b, err := json.Marshal(storedValue{typ, value})
+	if err != nil {
+		log.Info.Println(err)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pending[key] = b
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.delay, s.flush)
+	}
+}
+
+// flush writes the pending values to the storage.
+func (s *valueStore) flush() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	for key, b := range s.pending {
+		if err := s.storage.Set(key, b); err != nil {
+			log.Info.Println(err)
+		}
+	}
+
+	s.pending = map[string][]byte{}
+}
+
+// persistent returns true when the value of c should be stored.
+// Only values of writable characteristics, which are not write-only, are stored.
+func persistent(c *characteristic.Characteristic) bool {
+	var read, write bool
+	for _, p := range c.Perms {
+		switch p {
+		case characteristic.PermRead:
+			read = true
+		case characteristic.PermWrite:
+			write = true
+		}
+	}
+
+	return read && write
+}
+
+func valueKey(a *accessory.Accessory, c *characteristic.Characteristic) string {
+	return fmt.Sprintf("%d.%d.value", a.GetID(), c.GetID())
+}
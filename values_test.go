@@ -0,0 +1,91 @@
+package hc
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/util"
+
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPersistValues(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	config := Config{StoragePath: dir, PersistValues: true, PersistDelay: time.Hour}
+
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	tr, err := NewIPTransport(config, sw.Accessory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sw.Switch.On.SetValue(true)
+
+	// Value is not stored before the delay elapsed
+	if b, _ := tr.storage.Get(valueKey(sw.Accessory, sw.Switch.On.Characteristic)); len(b) != 0 {
+		t.Fatal(string(b))
+	}
+
+	tr.values.flush()
+
+	restored := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	if _, err := NewIPTransport(config, restored.Accessory); err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := restored.Switch.On.GetValue(), true; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestPersistValuesDisabled(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hc")
+	defer os.RemoveAll(dir)
+
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	tr, err := NewIPTransport(Config{StoragePath: dir}, sw.Accessory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tr.values != nil {
+		t.Fatal("values are stored")
+	}
+}
+
+func TestValueStoreDelay(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	s := newValueStore(storage, 10*time.Millisecond)
+
+	s.set("1.10.value", "25", false)
+	s.set("1.10.value", "25", true)
+
+	time.Sleep(50 * time.Millisecond)
+
+	b, err := storage.Get("1.10.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if is, want := string(b), `{"type":"25","value":true}`; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}
+
+func TestRestoreIgnoresOtherType(t *testing.T) {
+	storage, _ := util.NewTempFileStorage()
+	s := newValueStore(storage, time.Second)
+
+	sw := accessory.NewSwitch(accessory.Info{Name: "Switch"})
+	accessory.NewContainer().AddAccessory(sw.Accessory)
+
+	storage.Set(valueKey(sw.Accessory, sw.Switch.On.Characteristic), []byte(`{"type":"8","value":true}`))
+	s.restore(sw.Accessory)
+
+	if is, want := sw.Switch.On.GetValue(), false; is != want {
+		t.Fatalf("is=%v want=%v", is, want)
+	}
+}